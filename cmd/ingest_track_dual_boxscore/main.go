@@ -9,6 +9,7 @@ import (
 
 	"gable-backend/database"
 	"gable-backend/internal/ingest/trackdual"
+	"gable-backend/internal/stats"
 )
 
 func main() {
@@ -32,7 +33,7 @@ func main() {
 
 	database.ConnectDB()
 	repo := trackdual.NewPostgresRepository(database.DB)
-	service := trackdual.NewService(repo)
+	service := trackdual.NewService(repo).WithRefresh(stats.NewStore(database.DB))
 
 	result, err := service.Process(context.Background(), records)
 	if err != nil {
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"gable-backend/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+type APITierRow struct {
+	Name        string `json:"name"`
+	PerMinute   int    `json:"perMinute"`
+	PerDay      int    `json:"perDay"`
+	Description string `json:"description"`
+	Keys        int    `json:"keys"`
+}
+
+type APIKeyUsageRow struct {
+	Subject     string  `json:"subject"`
+	KeyID       *string `json:"keyId"`
+	KeyName     *string `json:"keyName"`
+	Prefix      *string `json:"prefix"`
+	OwnerEmail  *string `json:"ownerEmail"`
+	Tier        *string `json:"tier"`
+	Requests    int64   `json:"requests"`
+	Errors      int64   `json:"errors"`
+	RateLimited int64   `json:"rateLimited"`
+}
+
+type APIRouteUsageRow struct {
+	Route    string `json:"route"`
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"`
+}
+
+// GET /api/admin/api/tiers
+func ListAPITiers(c *fiber.Ctx) error {
+	rows, err := database.DB.Query(`
+		SELECT t.name, t.per_minute, t.per_day, COALESCE(t.description, ''),
+		       COUNT(k.id) FILTER (WHERE k.revoked_at IS NULL)
+		FROM api_tiers t
+		LEFT JOIN api_keys k ON k.tier = t.name
+		GROUP BY t.name
+		ORDER BY t.per_minute
+	`)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list tiers"})
+	}
+	defer rows.Close()
+
+	tiers := make([]APITierRow, 0)
+	for rows.Next() {
+		var t APITierRow
+		if err := rows.Scan(&t.Name, &t.PerMinute, &t.PerDay, &t.Description, &t.Keys); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read tiers"})
+		}
+		tiers = append(tiers, t)
+	}
+	return c.JSON(tiers)
+}
+
+// GET /api/admin/api/usage?days=7
+// Requests per key (anonymous traffic as one row) and per route over the
+// last N days, busiest first.
+func GetAPIUsageSummary(c *fiber.Ctx) error {
+	days := usageDays(c)
+
+	rows, err := database.DB.Query(`
+		SELECT u.subject, k.id::TEXT, k.name, k.prefix, us.email, k.tier,
+		       SUM(u.count),
+		       SUM(u.count) FILTER (WHERE u.status >= 400),
+		       SUM(u.count) FILTER (WHERE u.status = 429)
+		FROM api_usage_daily u
+		LEFT JOIN api_keys k ON 'key:' || k.id::TEXT = u.subject
+		LEFT JOIN users us   ON us.id = k.user_id
+		WHERE u.day > CURRENT_DATE - $1::INT
+		GROUP BY u.subject, k.id, us.email
+		ORDER BY SUM(u.count) DESC
+		LIMIT 200
+	`, days)
+	if err != nil {
+		log.Printf("api usage summary: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load usage"})
+	}
+	defer rows.Close()
+
+	keys := make([]APIKeyUsageRow, 0)
+	for rows.Next() {
+		var r APIKeyUsageRow
+		var errCount, limited sql.NullInt64
+		if err := rows.Scan(&r.Subject, &r.KeyID, &r.KeyName, &r.Prefix, &r.OwnerEmail, &r.Tier,
+			&r.Requests, &errCount, &limited); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read usage"})
+		}
+		r.Errors = errCount.Int64
+		r.RateLimited = limited.Int64
+		keys = append(keys, r)
+	}
+
+	routeRows, err := database.DB.Query(`
+		SELECT route, SUM(count), COALESCE(SUM(count) FILTER (WHERE status >= 400), 0)
+		FROM api_usage_daily
+		WHERE day > CURRENT_DATE - $1::INT
+		GROUP BY route
+		ORDER BY SUM(count) DESC
+	`, days)
+	if err != nil {
+		log.Printf("api usage summary: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load usage"})
+	}
+	defer routeRows.Close()
+
+	routes := make([]APIRouteUsageRow, 0)
+	for routeRows.Next() {
+		var r APIRouteUsageRow
+		if err := routeRows.Scan(&r.Route, &r.Requests, &r.Errors); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read usage"})
+		}
+		routes = append(routes, r)
+	}
+
+	return c.JSON(fiber.Map{
+		"days":   days,
+		"keys":   keys,
+		"routes": routes,
+	})
+}
+
+// PUT /api/admin/api/keys/:id/tier  {"tier": "partner"}
+func SetAPIKeyTier(c *fiber.Ctx) error {
+	var req struct {
+		Tier string `json:"tier"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid JSON"})
+	}
+	req.Tier = strings.TrimSpace(req.Tier)
+	if req.Tier == "" || req.Tier == "anonymous" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A key tier is required"})
+	}
+
+	keyID := c.Params("id")
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start transaction"})
+	}
+	defer tx.Rollback()
+
+	var previous string
+	err = tx.QueryRow(`SELECT tier FROM api_keys WHERE id::text = $1 FOR UPDATE`, keyID).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "API key not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update tier"})
+	}
+
+	if _, err := tx.Exec(`UPDATE api_keys SET tier = $1 WHERE id::text = $2`, req.Tier, keyID); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tier not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update tier"})
+	}
+	if err := recordAudit(c, tx, auditEntry{
+		Action:     "api.key.tier",
+		TargetType: "api_key",
+		TargetID:   keyID,
+		Before:     fiber.Map{"tier": previous},
+		After:      fiber.Map{"tier": req.Tier},
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update tier"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update tier"})
+	}
+	return c.JSON(fiber.Map{"keyId": c.Params("id"), "tier": req.Tier})
+}
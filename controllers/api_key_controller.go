@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/internal/apikey"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+const (
+	maxActiveAPIKeys  = 10
+	maxAPIKeyNameLen  = 64
+	maxAPIKeyLifetime = 365 // days
+)
+
+type APIKeyRow struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	Tier       string     `json:"tier"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RotatedAt  *time.Time `json:"rotated_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+const apiKeyColumns = `id, name, prefix, scopes, tier, created_at, rotated_at, last_used_at, expires_at, revoked_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (APIKeyRow, error) {
+	var k APIKeyRow
+	var rotated, lastUsed, expires, revoked sql.NullTime
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.Tier, &k.CreatedAt,
+		&rotated, &lastUsed, &expires, &revoked)
+	if err != nil {
+		return k, err
+	}
+	k.RotatedAt = nullTimePtr(rotated)
+	k.LastUsedAt = nullTimePtr(lastUsed)
+	k.ExpiresAt = nullTimePtr(expires)
+	k.RevokedAt = nullTimePtr(revoked)
+	return k, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// requireVerifiedUser returns the caller's id, or writes a 401/403 response.
+func requireVerifiedUser(c *fiber.Ctx) (int, bool, error) {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return 0, false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	var verified bool
+	if err := database.DB.QueryRow(`SELECT verified FROM users WHERE id = $1`, userID).Scan(&verified); err != nil {
+		return 0, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+	if !verified {
+		return 0, false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":                "Email not verified",
+			"requiresVerification": true,
+		})
+	}
+	return userID, true, nil
+}
+
+// GET /api/gable/user/api-keys
+func ListAPIKeys(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY revoked_at IS NOT NULL, created_at DESC
+	`, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list API keys"})
+	}
+	defer rows.Close()
+
+	keys := make([]APIKeyRow, 0)
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read API keys"})
+		}
+		keys = append(keys, k)
+	}
+	return c.JSON(keys)
+}
+
+// POST /api/gable/user/api-keys  {"name": "...", "scopes": [...], "expires_in_days": 90}
+// The full key is returned only in this response.
+func CreateAPIKey(c *fiber.Ctx) error {
+	userID, ok, err := requireVerifiedUser(c)
+	if !ok {
+		return err
+	}
+
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"` // 0 = never
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxAPIKeyNameLen {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name is required (max 64 characters)"})
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxAPIKeyLifetime {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "expires_in_days must be between 0 and 365"})
+	}
+	scopes, err := apikey.NormalizeScopes(req.Scopes)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown scope", "valid_scopes": apikey.AllScopes})
+	}
+
+	var active int
+	if err := database.DB.QueryRow(`
+		SELECT COUNT(*) FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())
+	`, userID).Scan(&active); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create API key"})
+	}
+	if active >= maxActiveAPIKeys {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Too many active API keys; revoke one first"})
+	}
+
+	key, err := apikey.Generate()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create API key"})
+	}
+
+	var expiresAt any
+	if req.ExpiresInDays > 0 {
+		expiresAt = time.Now().AddDate(0, 0, req.ExpiresInDays)
+	}
+
+	row, err := scanAPIKey(database.DB.QueryRow(`
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+apiKeyColumns,
+		userID, req.Name, key.Prefix, key.Hash, pq.Array(scopes), expiresAt))
+	if err != nil {
+		log.Printf("create api key: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create API key"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"key": key.Full, "api_key": row})
+}
+
+// POST /api/gable/user/api-keys/:id/rotate
+// Replaces the secret in place; the old key stops working immediately while
+// the id, scopes and usage history carry over.
+func RotateAPIKey(c *fiber.Ctx) error {
+	userID, ok, err := requireVerifiedUser(c)
+	if !ok {
+		return err
+	}
+
+	key, err := apikey.Generate()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to rotate API key"})
+	}
+
+	row, err := scanAPIKey(database.DB.QueryRow(`
+		UPDATE api_keys
+		SET prefix = $3, key_hash = $4, rotated_at = now()
+		WHERE id::text = $1 AND user_id = $2
+		  AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())
+		RETURNING `+apiKeyColumns,
+		c.Params("id"), userID, key.Prefix, key.Hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "API key not found"})
+	}
+	if err != nil {
+		log.Printf("rotate api key: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to rotate API key"})
+	}
+
+	return c.JSON(fiber.Map{"key": key.Full, "api_key": row})
+}
+
+// DELETE /api/gable/user/api-keys/:id
+func RevokeAPIKey(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	res, err := database.DB.Exec(`
+		UPDATE api_keys SET revoked_at = now()
+		WHERE id::text = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, c.Params("id"), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke API key"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "API key not found"})
+	}
+	return c.JSON(fiber.Map{"revoked": true})
+}
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gable-backend/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// auditEntry describes one admin change. Before and After are marshalled to
+// JSON; leave either nil when it does not apply (creates have no Before).
+type auditEntry struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+}
+
+// recordAudit writes e to audit_log, attributed to the authenticated caller.
+// Pass the handler's transaction when there is one so the entry commits or
+// rolls back with the change itself.
+func recordAudit(c *fiber.Ctx, db execer, e auditEntry) error {
+	before, err := auditJSON(e.Before)
+	if err != nil {
+		return err
+	}
+	after, err := auditJSON(e.After)
+	if err != nil {
+		return err
+	}
+
+	var actorID any
+	if id, ok := c.Locals("user_id").(int); ok {
+		actorID = id
+	}
+	actorEmail, _ := c.Locals("email").(string)
+
+	_, err = db.Exec(`
+		INSERT INTO audit_log
+			(actor_user_id, actor_email, action, target_type, target_id, before, after, ip_address, user_agent)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
+	`, actorID, actorEmail, e.Action, e.TargetType, e.TargetID, before, after, c.IP(), c.Get(fiber.HeaderUserAgent))
+	return err
+}
+
+// recordAuditOutsideTx is for changes that are already committed (or made by
+// code that owns its own transaction). A failure is logged, not returned,
+// because the change cannot be undone at this point.
+func recordAuditOutsideTx(c *fiber.Ctx, e auditEntry) {
+	if err := recordAudit(c, database.DB, e); err != nil {
+		log.Printf("audit log write failed: action=%s target=%s/%s: %v", e.Action, e.TargetType, e.TargetID, err)
+	}
+}
+
+func auditJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+type AuditLogRow struct {
+	ID          int64           `json:"id"`
+	ActorUserID *int            `json:"actorUserId"`
+	ActorEmail  *string         `json:"actorEmail"`
+	Action      string          `json:"action"`
+	TargetType  string          `json:"targetType"`
+	TargetID    *string         `json:"targetId"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	IPAddress   *string         `json:"ipAddress"`
+	UserAgent   *string         `json:"userAgent"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// GET /api/admin/audit
+// Query params: actor (user id or email), action (exact, or prefix ending in
+// "."), targetType, targetId, since / until (RFC 3339 or YYYY-MM-DD),
+// limit (default 50, max 200), beforeId (for the next page).
+// Newest first; nextBeforeId is null on the last page.
+func ListAuditLog(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	where := []string{}
+	args := []any{}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if actor := strings.TrimSpace(c.Query("actor")); actor != "" {
+		if id, err := strconv.Atoi(actor); err == nil {
+			add("actor_user_id = ?", id)
+		} else {
+			add("lower(actor_email) = lower(?)", actor)
+		}
+	}
+	if action := strings.TrimSpace(c.Query("action")); action != "" {
+		if strings.HasSuffix(action, ".") {
+			add("action LIKE ? || '%'", action)
+		} else {
+			add("action = ?", action)
+		}
+	}
+	if v := strings.TrimSpace(c.Query("targetType")); v != "" {
+		add("target_type = ?", v)
+	}
+	if v := strings.TrimSpace(c.Query("targetId")); v != "" {
+		add("target_id = ?", v)
+	}
+	for _, p := range []struct{ param, cond string }{
+		{"since", "created_at >= ?"},
+		{"until", "created_at < ?"},
+	} {
+		raw := strings.TrimSpace(c.Query(p.param))
+		if raw == "" {
+			continue
+		}
+		t, err := parseAuditTime(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": p.param + " must be RFC 3339 or YYYY-MM-DD"})
+		}
+		add(p.cond, t)
+	}
+	if v := strings.TrimSpace(c.Query("beforeId")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid beforeId"})
+		}
+		add("id < ?", id)
+	}
+
+	query := `
+		SELECT id, actor_user_id, actor_email, action, target_type, target_id,
+		       before, after, ip_address, user_agent, created_at
+		FROM audit_log`
+	if len(where) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, limit+1)
+	query += "\n\t\tORDER BY id DESC\n\t\tLIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		log.Printf("list audit log: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load audit log"})
+	}
+	defer rows.Close()
+
+	entries := make([]AuditLogRow, 0, limit)
+	for rows.Next() {
+		var e AuditLogRow
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.ActorUserID, &e.ActorEmail, &e.Action, &e.TargetType, &e.TargetID,
+			&before, &after, &e.IPAddress, &e.UserAgent, &e.CreatedAt); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read audit log"})
+		}
+		if before != nil {
+			e.Before = before
+		}
+		if after != nil {
+			e.After = after
+		}
+		entries = append(entries, e)
+	}
+
+	var nextBeforeID *int64
+	if len(entries) > limit {
+		entries = entries[:limit]
+		nextBeforeID = &entries[limit-1].ID
+	}
+
+	return c.JSON(fiber.Map{
+		"entries":      entries,
+		"nextBeforeId": nextBeforeID,
+	})
+}
+
+func parseAuditTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
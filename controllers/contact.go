@@ -1,13 +1,20 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
 	"os"
 	"strings"
 
+	"gable-backend/database"
+	"gable-backend/mail"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/sendgrid/sendgrid-go"
-	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+const (
+	maxContactNameLen    = 100
+	maxContactMessageLen = 5000
 )
 
 type ContactForm struct {
@@ -15,6 +22,20 @@ type ContactForm struct {
 	Message string `json:"message"`
 }
 
+// contactNotifyRecipients reads CONTACT_NOTIFY_EMAILS, a comma-separated list.
+// With none configured, messages are only kept in the admin inbox.
+func contactNotifyRecipients() []string {
+	var out []string
+	for _, addr := range strings.Split(os.Getenv("CONTACT_NOTIFY_EMAILS"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
+// ContactHandler saves the message to the contact inbox and queues a
+// notification to CONTACT_NOTIFY_EMAILS in the same transaction.
 func ContactHandler(c *fiber.Ctx) error {
 	// Extract user email from context
 	emailValue := c.Locals("email")
@@ -32,28 +53,59 @@ func ContactHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	if len(strings.TrimSpace(form.Message)) < 15 {
+	name := strings.TrimSpace(form.Name)
+	message := strings.TrimSpace(form.Message)
+	if len(message) < 15 {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"error": "Message is too short",
 		})
 	}
+	if len(message) > maxContactMessageLen {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Message is too long",
+		})
+	}
+	if r := []rune(name); len(r) > maxContactNameLen {
+		name = string(r[:maxContactNameLen])
+	}
 
-	sender := mail.NewEmail("Gable Game Contact", "noreply@gablegame.com")
-	recipient := mail.NewEmail("Sammy", "sammy.cokeley@gmail.com")
-	subject := "[Gable Game] New Contact Message"
-
-	plainText := "From: " + form.Name + "\nEmail: " + email + "\n\n" + form.Message
-	htmlText := "<strong>From:</strong> " + form.Name + "<br><strong>Email:</strong> " + email + "<br><br>" + form.Message
-
-	message := mail.NewSingleEmail(sender, subject, recipient, plainText, htmlText)
-	client := sendgrid.NewSendClient(os.Getenv("SENDGRID_API_KEY"))
-
-	_, err := client.Send(message)
-	if err != nil {
+	failed := func(err error) error {
+		log.Printf("contact save error: %v", err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to send message",
 		})
 	}
 
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return failed(err)
+	}
+	defer tx.Rollback()
+
+	var userID any
+	if id, ok := c.Locals("user_id").(int); ok {
+		userID = id
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO contact_messages (user_id, email, name, message)
+		VALUES ($1, $2, $3, $4)
+	`, userID, email, name, message); err != nil {
+		return failed(err)
+	}
+
+	if to := contactNotifyRecipients(); len(to) > 0 {
+		msg, err := mail.ContactNotification(to, name, email, message)
+		if err == nil {
+			err = mail.Enqueue(tx, "contact_notification", msg)
+		}
+		if err != nil {
+			return failed(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return failed(err)
+	}
+
 	return c.SendStatus(http.StatusOK)
 }
@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/mail"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ContactMessage struct {
+	ID        int64          `json:"id"`
+	UserID    *int           `json:"userId"`
+	Email     string         `json:"email"`
+	Name      string         `json:"name"`
+	Message   string         `json:"message"`
+	Status    string         `json:"status"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	ClosedAt  *time.Time     `json:"closedAt"`
+	Replies   []ContactReply `json:"replies,omitempty"`
+}
+
+type ContactReply struct {
+	ID         int64     `json:"id"`
+	AdminEmail *string   `json:"adminEmail"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+var contactStatuses = map[string]bool{"new": true, "replied": true, "closed": true}
+
+const contactMessageColumns = `id, user_id, email, name, message, status, created_at, updated_at, closed_at`
+
+func scanContactMessage(row interface{ Scan(...any) error }) (ContactMessage, error) {
+	var (
+		m        ContactMessage
+		userID   sql.NullInt64
+		closedAt sql.NullTime
+	)
+	err := row.Scan(&m.ID, &userID, &m.Email, &m.Name, &m.Message, &m.Status, &m.CreatedAt, &m.UpdatedAt, &closedAt)
+	if userID.Valid {
+		id := int(userID.Int64)
+		m.UserID = &id
+	}
+	m.ClosedAt = nullTimePtr(closedAt)
+	return m, err
+}
+
+// GET /api/admin/contact?status=new&limit=50&beforeId=123
+// Without status, returns every open (new or replied) message, newest first.
+func ListContactMessages(c *fiber.Ctx) error {
+	status := c.Query("status")
+	if status != "" && !contactStatuses[status] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be new, replied or closed"})
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	beforeID := int64(c.QueryInt("beforeId", 0))
+
+	rows, err := database.DB.Query(`
+		SELECT `+contactMessageColumns+`
+		FROM contact_messages
+		WHERE (($1 = '' AND status <> 'closed') OR status = $1)
+		  AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, status, beforeID, limit)
+	if err != nil {
+		log.Printf("list contact messages: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list messages"})
+	}
+	defer rows.Close()
+
+	out := make([]ContactMessage, 0)
+	for rows.Next() {
+		m, err := scanContactMessage(rows)
+		if err != nil {
+			log.Printf("scan contact message: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list messages"})
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list messages"})
+	}
+
+	var nextBeforeID *int64
+	if len(out) == limit {
+		nextBeforeID = &out[len(out)-1].ID
+	}
+	return c.JSON(fiber.Map{"messages": out, "nextBeforeId": nextBeforeID})
+}
+
+// GET /api/admin/contact/:id
+// Returns the message with its replies, oldest first.
+func GetContactMessage(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	m, err := scanContactMessage(database.DB.QueryRow(
+		`SELECT `+contactMessageColumns+` FROM contact_messages WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Message not found"})
+	}
+	if err != nil {
+		log.Printf("get contact message: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load message"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, admin_email, body, created_at
+		FROM contact_replies
+		WHERE contact_message_id = $1
+		ORDER BY created_at, id
+	`, id)
+	if err != nil {
+		log.Printf("list contact replies: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load message"})
+	}
+	defer rows.Close()
+
+	m.Replies = make([]ContactReply, 0)
+	for rows.Next() {
+		var (
+			r          ContactReply
+			adminEmail sql.NullString
+		)
+		if err := rows.Scan(&r.ID, &adminEmail, &r.Body, &r.CreatedAt); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load message"})
+		}
+		if adminEmail.Valid {
+			r.AdminEmail = &adminEmail.String
+		}
+		m.Replies = append(m.Replies, r)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load message"})
+	}
+
+	return c.JSON(m)
+}
+
+// POST /api/admin/contact/:id/reply  {"body": "..."}
+// Emails the reply to the sender (through the outbox) and marks the message
+// replied. Closed messages cannot be replied to.
+func ReplyContactMessage(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	var data struct {
+		Body string `json:"body"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON body"})
+	}
+	body := strings.TrimSpace(data.Body)
+	if body == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "body is required"})
+	}
+	if len(body) > maxContactMessageLen {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "body is too long"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to send reply"})
+	}
+	defer tx.Rollback()
+
+	m, err := scanContactMessage(tx.QueryRow(
+		`SELECT `+contactMessageColumns+` FROM contact_messages WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Message not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to send reply"})
+	}
+	if m.Status == "closed" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Message is closed"})
+	}
+
+	var adminID any
+	if uid, ok := c.Locals("user_id").(int); ok {
+		adminID = uid
+	}
+	adminEmail, _ := c.Locals("email").(string)
+
+	var replyID int64
+	if err := tx.QueryRow(`
+		INSERT INTO contact_replies (contact_message_id, admin_user_id, admin_email, body)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING id
+	`, id, adminID, adminEmail, body).Scan(&replyID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to send reply"})
+	}
+	if _, err := tx.Exec(`
+		UPDATE contact_messages SET status = 'replied', updated_at = now() WHERE id = $1
+	`, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to send reply"})
+	}
+
+	msg, err := mail.ContactReply(m.Email, m.Name, body, m.Message)
+	if err == nil {
+		err = mail.Enqueue(tx, "contact_reply", msg)
+	}
+	if err != nil {
+		log.Printf("queue contact reply: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to send reply"})
+	}
+
+	if err := recordAudit(c, tx, auditEntry{
+		Action:     "contact.message.reply",
+		TargetType: "contact_message",
+		TargetID:   strconv.FormatInt(id, 10),
+		Before:     fiber.Map{"status": m.Status},
+		After:      fiber.Map{"status": "replied", "replyId": replyID},
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to send reply"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to send reply"})
+	}
+
+	return c.JSON(fiber.Map{"id": id, "status": "replied", "replyId": replyID})
+}
+
+// POST /api/admin/contact/:id/close
+func CloseContactMessage(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to close message"})
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM contact_messages WHERE id = $1 FOR UPDATE`, id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Message not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to close message"})
+	}
+	if status == "closed" {
+		return c.JSON(fiber.Map{"id": id, "status": "closed"})
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE contact_messages SET status = 'closed', closed_at = now(), updated_at = now() WHERE id = $1
+	`, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to close message"})
+	}
+	if err := recordAudit(c, tx, auditEntry{
+		Action:     "contact.message.close",
+		TargetType: "contact_message",
+		TargetID:   strconv.FormatInt(id, 10),
+		Before:     fiber.Map{"status": status},
+		After:      fiber.Map{"status": "closed"},
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to close message"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to close message"})
+	}
+
+	return c.JSON(fiber.Map{"id": id, "status": "closed"})
+}
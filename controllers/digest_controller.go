@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/internal/digest"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+const (
+	maxDigestSchools   = 25
+	maxDigestWrestlers = 50
+)
+
+func digestStore() *digest.Store { return digest.NewStore(database.DB, digest.ConfigFromEnv()) }
+
+type WrestlerRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type DigestSubscription struct {
+	Enabled       bool          `json:"enabled"`
+	WeightClasses []string      `json:"weight_classes"`
+	Schools       []SchoolRef   `json:"schools"`
+	Wrestlers     []WrestlerRef `json:"wrestlers"`
+}
+
+func loadDigestSubscription(userID int) (DigestSubscription, error) {
+	sub := DigestSubscription{WeightClasses: []string{}, Schools: []SchoolRef{}, Wrestlers: []WrestlerRef{}}
+	var schoolIDs, wrestlerIDs []string
+	err := database.DB.QueryRow(`
+		SELECT enabled, weight_classes, school_ids::TEXT[], wrestler_ids::TEXT[]
+		FROM digest_subscriptions WHERE user_id = $1
+	`, userID).Scan(&sub.Enabled, pq.Array(&sub.WeightClasses), pq.Array(&schoolIDs), pq.Array(&wrestlerIDs))
+	if errors.Is(err, sql.ErrNoRows) {
+		return sub, nil
+	}
+	if err != nil {
+		return sub, err
+	}
+
+	rows, err := database.DB.Query(`SELECT name, slug FROM core.school WHERE id::TEXT = ANY($1) ORDER BY name`, pq.Array(schoolIDs))
+	if err != nil {
+		return sub, err
+	}
+	for rows.Next() {
+		var s SchoolRef
+		if err := rows.Scan(&s.Name, &s.Slug); err != nil {
+			rows.Close()
+			return sub, err
+		}
+		sub.Schools = append(sub.Schools, s)
+	}
+	rows.Close()
+
+	rows, err = database.DB.Query(`SELECT id, full_name, slug FROM core.wrestler WHERE id::TEXT = ANY($1) ORDER BY full_name`, pq.Array(wrestlerIDs))
+	if err != nil {
+		return sub, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var w WrestlerRef
+		if err := rows.Scan(&w.ID, &w.Name, &w.Slug); err != nil {
+			return sub, err
+		}
+		sub.Wrestlers = append(sub.Wrestlers, w)
+	}
+	return sub, rows.Err()
+}
+
+// resolveRefs maps each requested key to an id using query, which must select
+// (key, id) pairs for keys in $1. Unknown keys are returned separately.
+func resolveRefs(query string, keys []string) (ids, unknown []string, err error) {
+	rows, err := database.DB.Query(query, pq.Array(keys))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	found := map[string]string{}
+	for rows.Next() {
+		var key, id string
+		if err := rows.Scan(&key, &id); err != nil {
+			return nil, nil, err
+		}
+		found[key] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	seen := map[string]bool{}
+	ids = []string{}
+	for _, k := range keys {
+		id, ok := found[k]
+		if !ok {
+			unknown = append(unknown, k)
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, unknown, nil
+}
+
+func trimAllNonEmpty(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// GET /api/gable/user/digest
+func GetDigestSubscription(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	sub, err := loadDigestSubscription(userID)
+	if err != nil {
+		log.Printf("load digest subscription: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load subscription"})
+	}
+	return c.JSON(sub)
+}
+
+// PUT /api/gable/user/digest
+// Partial update: omitted fields are left unchanged; an empty list follows
+// everything for that dimension. schools and wrestlers take slugs. New
+// subscriptions start disabled unless "enabled": true is sent, which
+// requires a verified email.
+func UpdateDigestSubscription(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req struct {
+		Enabled       *bool     `json:"enabled"`
+		WeightClasses *[]string `json:"weight_classes"`
+		Schools       *[]string `json:"schools"`
+		Wrestlers     *[]string `json:"wrestlers"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	if req.Enabled != nil && *req.Enabled {
+		if _, ok, resp := requireVerifiedUser(c); !ok {
+			return resp
+		}
+	}
+
+	var weights, schoolIDs, wrestlerIDs []string // nil leaves the column unchanged
+	if req.WeightClasses != nil {
+		labels := trimAllNonEmpty(*req.WeightClasses)
+		ids, unknown, err := resolveRefs(`SELECT label, label FROM core.weight_class WHERE label = ANY($1)`, labels)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to look up weight classes"})
+		}
+		if len(unknown) > 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown weight class", "unknown": unknown})
+		}
+		weights = ids
+	}
+	if req.Schools != nil {
+		slugs := trimAllNonEmpty(*req.Schools)
+		if len(slugs) > maxDigestSchools {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Follow at most " + strconv.Itoa(maxDigestSchools) + " schools"})
+		}
+		ids, unknown, err := resolveRefs(`SELECT slug, id::TEXT FROM core.school WHERE slug = ANY($1)`, slugs)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to look up schools"})
+		}
+		if len(unknown) > 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown school", "unknown": unknown})
+		}
+		schoolIDs = ids
+	}
+	if req.Wrestlers != nil {
+		slugs := trimAllNonEmpty(*req.Wrestlers)
+		if len(slugs) > maxDigestWrestlers {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Follow at most " + strconv.Itoa(maxDigestWrestlers) + " wrestlers"})
+		}
+		ids, unknown, err := resolveRefs(`SELECT slug, id::TEXT FROM core.wrestler WHERE slug = ANY($1)`, slugs)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to look up wrestlers"})
+		}
+		if len(unknown) > 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown wrestler", "unknown": unknown})
+		}
+		wrestlerIDs = ids
+	}
+
+	_, err := database.DB.Exec(`
+		INSERT INTO digest_subscriptions (user_id, enabled, weight_classes, school_ids, wrestler_ids)
+		VALUES ($1, COALESCE($2, false), COALESCE($3::TEXT[], '{}'), COALESCE($4::UUID[], '{}'), COALESCE($5::UUID[], '{}'))
+		ON CONFLICT (user_id) DO UPDATE SET
+			enabled        = COALESCE($2, digest_subscriptions.enabled),
+			weight_classes = COALESCE($3::TEXT[], digest_subscriptions.weight_classes),
+			school_ids     = COALESCE($4::UUID[], digest_subscriptions.school_ids),
+			wrestler_ids   = COALESCE($5::UUID[], digest_subscriptions.wrestler_ids),
+			updated_at     = now()
+	`, userID, req.Enabled, pq.Array(weights), pq.Array(schoolIDs), pq.Array(wrestlerIDs))
+	if err != nil {
+		log.Printf("update digest subscription: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save subscription"})
+	}
+
+	sub, err := loadDigestSubscription(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load subscription"})
+	}
+	return c.JSON(sub)
+}
+
+// POST /api/gable/digest/unsubscribe?token=...
+// Also accepts {"token": "..."}. No sign-in: the token in every digest email
+// identifies the user, and mail clients post here for one-click unsubscribe.
+func UnsubscribeDigest(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		var data struct {
+			Token string `json:"token"`
+		}
+		_ = c.BodyParser(&data)
+		token = data.Token
+	}
+
+	userID, err := digest.ParseUnsubscribeToken(digest.ConfigFromEnv().Secret, token)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid unsubscribe link", "code": "token_invalid"})
+	}
+	if _, err := database.DB.Exec(`
+		UPDATE digest_subscriptions SET enabled = false, updated_at = now()
+		WHERE user_id = $1 AND enabled
+	`, userID); err != nil {
+		log.Printf("digest unsubscribe: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to unsubscribe"})
+	}
+	return c.JSON(fiber.Map{"message": "You have been unsubscribed from the weekly digest."})
+}
+
+// GET /api/admin/digest/preview?userId=7&week=2026-10-19
+// Renders the digest that would go out for the week starting on week (a
+// Monday; defaults to the current week) without sending or marking anything.
+// Without userId the unfiltered digest is rendered for the caller.
+func PreviewDigest(c *fiber.Ctx) error {
+	store := digestStore()
+	loc := store.Location()
+
+	week := digest.WeekStart(time.Now(), loc)
+	if w := c.Query("week"); w != "" {
+		t, err := time.ParseInLocation("2006-01-02", w, loc)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "week must be YYYY-MM-DD"})
+		}
+		week = digest.WeekStart(t, loc)
+	}
+
+	sub := digest.Subscriber{}
+	if id := c.QueryInt("userId", 0); id > 0 {
+		s, err := store.Subscriber(c.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		if err != nil {
+			log.Printf("digest preview subscriber: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build preview"})
+		}
+		sub = s
+	} else {
+		sub.UserID, _ = c.Locals("user_id").(int)
+		sub.Email, _ = c.Locals("email").(string)
+	}
+
+	sections, err := store.Sections(c.Context(), week)
+	if err != nil {
+		log.Printf("digest preview sections: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build preview"})
+	}
+	d := digest.Digest{WeekOf: week, Sections: sub.Filter.Apply(sections)}
+	msg, err := store.Email(sub, d)
+	if err != nil {
+		log.Printf("digest preview render: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build preview"})
+	}
+
+	return c.JSON(fiber.Map{
+		"weekOf":    week.Format("2006-01-02"),
+		"wouldSend": len(d.Sections) > 0,
+		"sections":  d.Sections,
+		"email":     msg,
+	})
+}
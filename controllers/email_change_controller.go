@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/internal/auth"
+	"gable-backend/mail"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const emailChangeTokenTTL = time.Hour
+
+// POST /api/gable/user/email  {"new_email": "...", "current_password": "..."}
+// Sends a confirmation link to the new address. The account email is not
+// changed until that link is used; wrong passwords count toward the account's
+// sign-in lockout.
+func RequestEmailChange(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var data struct {
+		NewEmail        string `json:"new_email"`
+		CurrentPassword string `json:"current_password"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	newEmail := normalizeEmail(data.NewEmail)
+	if addr, err := netmail.ParseAddress(newEmail); err != nil || addr.Address != newEmail {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid email address"})
+	}
+
+	var currentEmail, passwordHash string
+	var hasPassword bool
+	err := database.DB.QueryRow(`
+		SELECT email, password_hash, has_password FROM users WHERE id = $1
+	`, userID).Scan(&currentEmail, &passwordHash, &hasPassword)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+	if !hasPassword {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Set a password before changing your email"})
+	}
+
+	accountKey := throttlePair{loginAccountPolicy, normalizeEmail(currentEmail)}
+	if wait := checkThrottle(c, accountKey); wait > 0 {
+		return tooManyAttempts(c, wait)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(data.CurrentPassword)) != nil {
+		recordThrottleFailure(c, accountKey)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Incorrect password"})
+	}
+
+	if newEmail == normalizeEmail(currentEmail) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "That is already your email"})
+	}
+	var taken bool
+	if err := database.DB.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM users WHERE lower(email) = $1)`, newEmail,
+	).Scan(&taken); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check email"})
+	}
+	if taken {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Email is already in use"})
+	}
+
+	token, err := auth.GenerateToken(32)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate token"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start transaction"})
+	}
+	defer tx.Rollback()
+
+	// Only the latest request can be confirmed.
+	if _, err := tx.Exec(`
+		UPDATE email_change_requests
+		SET expires_at = LEAST(expires_at, now())
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create request"})
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO email_change_requests (user_id, new_email, token_hash, expires_at)
+		VALUES ($1, $2, $3, now() + $4 * interval '1 second')
+	`, userID, newEmail, auth.HashToken(token), int(emailChangeTokenTTL.Seconds())); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create request"})
+	}
+	confirmURL := fmt.Sprintf("%s/confirm-email-change?token=%s", os.Getenv("FRONTEND_URL"), url.QueryEscape(token))
+	if err := mail.EnqueueEmailChangeConfirmation(tx, newEmail, confirmURL); err != nil {
+		log.Printf("queue email change confirmation: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create request"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create request"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":       "Check your new email for a confirmation link.",
+		"pending_email": newEmail,
+	})
+}
+
+// POST /api/gable/user/email/confirm  {"token": "..."}
+// Switches the account to the confirmed address and notifies the old one.
+// Unauthenticated on purpose: the link may be opened on another device.
+func ConfirmEmailChange(c *fiber.Ctx) error {
+	var data struct {
+		Token string `json:"token"`
+	}
+	_ = c.BodyParser(&data)
+	token := strings.TrimSpace(data.Token)
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid confirmation link", "code": "token_invalid"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Confirmation failed"})
+	}
+	defer tx.Rollback()
+
+	var (
+		requestID int64
+		userID    int
+		newEmail  string
+		expiresAt time.Time
+		usedAt    sql.NullTime
+	)
+	err = tx.QueryRow(`
+		SELECT id, user_id, new_email, expires_at, used_at
+		FROM email_change_requests
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, auth.HashToken(token)).Scan(&requestID, &userID, &newEmail, &expiresAt, &usedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid confirmation link", "code": "token_invalid"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Confirmation failed"})
+	}
+	if usedAt.Valid {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "This link has already been used", "code": "token_used"})
+	}
+	if time.Now().After(expiresAt) {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": "This link has expired. Please request the change again.", "code": "token_expired"})
+	}
+
+	var oldEmail string
+	if err := tx.QueryRow(`SELECT email FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&oldEmail); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Confirmation failed"})
+	}
+
+	// The new address proved ownership, so the account stays verified.
+	_, err = tx.Exec(`UPDATE users SET email = $1, verified = true WHERE id = $2`, newEmail, userID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Email is already in use", "code": "email_taken"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Confirmation failed"})
+	}
+	if _, err := tx.Exec(`UPDATE email_change_requests SET used_at = now() WHERE id = $1`, requestID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Confirmation failed"})
+	}
+	if err := mail.EnqueueEmailChangedNotice(tx, oldEmail, newEmail); err != nil {
+		log.Printf("queue email changed notice: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Confirmation failed"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Confirmation failed"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Your email has been updated.", "email": newEmail})
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/mail"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OutboxEmail is the admin view of a queued message. Bodies are left out on
+// purpose: verification and email-change messages carry live tokens.
+type OutboxEmail struct {
+	ID            int64      `json:"id"`
+	Kind          string     `json:"kind"`
+	To            []string   `json:"to"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     *string    `json:"lastError"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	SentAt        *time.Time `json:"sentAt"`
+}
+
+var outboxStatuses = map[string]bool{"pending": true, "sending": true, "sent": true, "dead": true}
+
+// GET /api/admin/email/outbox?status=dead&limit=50&beforeId=123
+// Defaults to dead messages, newest first.
+func ListEmailOutbox(c *fiber.Ctx) error {
+	status := c.Query("status", "dead")
+	if !outboxStatuses[status] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be pending, sending, sent or dead"})
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	beforeID := int64(c.QueryInt("beforeId", 0))
+
+	rows, err := database.DB.Query(`
+		SELECT id, kind, message, status, attempts, last_error, next_attempt_at, created_at, sent_at
+		FROM email_outbox
+		WHERE status = $1 AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, status, beforeID, limit)
+	if err != nil {
+		log.Printf("list email outbox: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list email outbox"})
+	}
+	defer rows.Close()
+
+	out := make([]OutboxEmail, 0)
+	for rows.Next() {
+		var (
+			e         OutboxEmail
+			raw       []byte
+			lastError sql.NullString
+			sentAt    sql.NullTime
+		)
+		if err := rows.Scan(&e.ID, &e.Kind, &raw, &e.Status, &e.Attempts, &lastError, &e.NextAttemptAt, &e.CreatedAt, &sentAt); err != nil {
+			log.Printf("scan email outbox: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list email outbox"})
+		}
+		var msg mail.Message
+		if err := json.Unmarshal(raw, &msg); err == nil {
+			e.To, e.Subject = msg.To, msg.Subject
+		}
+		if lastError.Valid {
+			e.LastError = &lastError.String
+		}
+		e.SentAt = nullTimePtr(sentAt)
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list email outbox"})
+	}
+
+	var nextBeforeID *int64
+	if len(out) == limit {
+		nextBeforeID = &out[len(out)-1].ID
+	}
+	return c.JSON(fiber.Map{"messages": out, "nextBeforeId": nextBeforeID})
+}
+
+// POST /api/admin/email/outbox/:id/requeue
+// Puts a dead message back in the queue with a fresh attempt budget. The
+// worker discards bodies of messages left dead for a week, so those cannot
+// be sent again.
+func RequeueOutboxEmail(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to requeue"})
+	}
+	defer tx.Rollback()
+
+	var (
+		status    string
+		attempts  int
+		lastError sql.NullString
+		stored    []byte
+	)
+	err = tx.QueryRow(`
+		SELECT status, attempts, last_error, message
+		FROM email_outbox WHERE id = $1 FOR UPDATE
+	`, id).Scan(&status, &attempts, &lastError, &stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Message not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to requeue"})
+	}
+	switch err := mail.CheckRequeue(status, stored); {
+	case errors.Is(err, mail.ErrNotDead):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Only dead messages can be re-queued"})
+	case err != nil:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Message body was discarded; the user must request a new email"})
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE email_outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = now(), locked_until = NULL
+		WHERE id = $1
+	`, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to requeue"})
+	}
+
+	if err := recordAudit(c, tx, auditEntry{
+		Action:     "email.outbox.requeue",
+		TargetType: "email_outbox",
+		TargetID:   strconv.FormatInt(id, 10),
+		Before:     fiber.Map{"status": status, "attempts": attempts, "lastError": lastError.String},
+		After:      fiber.Map{"status": "pending", "attempts": 0},
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to requeue"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to requeue"})
+	}
+
+	return c.JSON(fiber.Map{"id": id, "status": "pending"})
+}
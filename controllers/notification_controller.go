@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/internal/reminder"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationPreferences struct {
+	DailyReminder bool   `json:"daily_reminder"`
+	ReminderTime  string `json:"reminder_time"` // HH:MM in TimeZone
+	TimeZone      string `json:"time_zone"`
+}
+
+func loadNotificationPreferences(userID int) (NotificationPreferences, error) {
+	p := NotificationPreferences{ReminderTime: "18:00", TimeZone: reminder.PuzzleTimeZone}
+	var at string
+	err := database.DB.QueryRow(`
+		SELECT daily_reminder, reminder_time::TEXT, time_zone
+		FROM notification_preferences WHERE user_id = $1
+	`, userID).Scan(&p.DailyReminder, &at, &p.TimeZone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	if clock, err := reminder.ParseClock(at); err == nil {
+		p.ReminderTime = clock.String()
+	}
+	return p, nil
+}
+
+// GET /api/gable/user/notifications
+func GetNotificationPreferences(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	p, err := loadNotificationPreferences(userID)
+	if err != nil {
+		log.Printf("load notification preferences: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load preferences"})
+	}
+	return c.JSON(p)
+}
+
+// PUT /api/gable/user/notifications
+// Partial update. reminder_time is HH:MM in time_zone (an IANA name such as
+// "America/Chicago"). Turning the reminder on requires a verified email.
+func UpdateNotificationPreferences(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req struct {
+		DailyReminder *bool   `json:"daily_reminder"`
+		ReminderTime  *string `json:"reminder_time"`
+		TimeZone      *string `json:"time_zone"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	if req.DailyReminder != nil && *req.DailyReminder {
+		if _, ok, resp := requireVerifiedUser(c); !ok {
+			return resp
+		}
+	}
+
+	var at, zone *string
+	if req.ReminderTime != nil {
+		clock, err := reminder.ParseClock(strings.TrimSpace(*req.ReminderTime))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		s := clock.String()
+		at = &s
+	}
+	if req.TimeZone != nil {
+		name := strings.TrimSpace(*req.TimeZone)
+		if _, err := time.LoadLocation(name); err != nil || name == "" || name == "Local" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown time zone"})
+		}
+		zone = &name
+	}
+
+	_, err := database.DB.Exec(`
+		INSERT INTO notification_preferences (user_id, daily_reminder, reminder_time, time_zone)
+		VALUES ($1, COALESCE($2, false), COALESCE($3::TIME, '18:00'), COALESCE($4, $5))
+		ON CONFLICT (user_id) DO UPDATE SET
+			daily_reminder = COALESCE($2, notification_preferences.daily_reminder),
+			reminder_time  = COALESCE($3::TIME, notification_preferences.reminder_time),
+			time_zone      = COALESCE($4, notification_preferences.time_zone),
+			updated_at     = now()
+	`, userID, req.DailyReminder, at, zone, reminder.PuzzleTimeZone)
+	if err != nil {
+		log.Printf("update notification preferences: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save preferences"})
+	}
+
+	p, err := loadNotificationPreferences(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load preferences"})
+	}
+	return c.JSON(p)
+}
@@ -0,0 +1,448 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/internal/auth"
+	"gable-backend/internal/oidc"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+// oidcBrowserCookie ties a flow to the browser that started it: it holds the
+// state hash, and the callback only accepts a state that matches it. Without
+// it, a provider URL started by one user (say, linking to their account)
+// could be completed in another user's browser.
+const (
+	oidcBrowserCookie = "gable_oidc"
+	oidcCallbackPath  = "/api/gable/oidc/callback"
+)
+
+// unusablePasswordHash is stored for accounts created through OIDC. It is not a
+// valid bcrypt hash, so password login always fails for them.
+const unusablePasswordHash = "!oidc"
+
+var (
+	oidcMu       sync.Mutex
+	oidcProvider *oidc.Provider
+
+	errOIDCNotConfigured   = errors.New("single sign-on is not configured")
+	errOIDCEmailUnverified = errors.New("identity provider did not confirm an email address")
+)
+
+type IdentityRow struct {
+	ID          int64      `json:"id"`
+	Issuer      string     `json:"issuer"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// getOIDCProvider discovers the provider configured by OIDC_ISSUER_URL on
+// first use. A failed discovery is retried on the next request.
+func getOIDCProvider(ctx context.Context) (*oidc.Provider, error) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+
+	if oidcProvider != nil {
+		return oidcProvider, nil
+	}
+
+	issuer := strings.TrimSpace(os.Getenv("OIDC_ISSUER_URL"))
+	clientID := strings.TrimSpace(os.Getenv("OIDC_CLIENT_ID"))
+	if issuer == "" || clientID == "" {
+		return nil, errOIDCNotConfigured
+	}
+
+	p, err := oidc.Discover(ctx, oidc.Config{
+		IssuerURL:    issuer,
+		ClientID:     clientID,
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	oidcProvider = p
+	return p, nil
+}
+
+// beginOIDCFlow records a login state row, binds it to the caller's browser
+// and returns the provider URL the browser should visit. linkUserID is 0 for
+// a plain sign-in.
+func beginOIDCFlow(c *fiber.Ctx, linkUserID int, redirectPath string) (string, error) {
+	ctx := c.UserContext()
+	p, err := getOIDCProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", err
+	}
+
+	var link any
+	if linkUserID > 0 {
+		link = linkUserID
+	}
+
+	// Expired states are swept opportunistically.
+	_, _ = database.DB.Exec(`DELETE FROM oidc_login_states WHERE expires_at < now()`)
+
+	stateHash := auth.HashToken(state)
+	_, err = database.DB.Exec(`
+		INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, link_user_id, redirect_path, expires_at)
+		VALUES ($1, $2, $3, $4, $5, now() + $6 * interval '1 second')
+	`, stateHash, verifier, nonce, link, redirectPath, int(oidcStateTTL.Seconds()))
+	if err != nil {
+		return "", err
+	}
+
+	// Lax still sends it on the provider's top-level redirect back to us.
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcBrowserCookie,
+		Value:    stateHash,
+		Path:     oidcCallbackPath,
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return p.AuthCodeURL(state, nonce, challenge), nil
+}
+
+// safeRedirectPath only allows same-site relative paths, to avoid an open redirect.
+func safeRedirectPath(p string) string {
+	p = strings.TrimSpace(p)
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.Contains(p, "\\") {
+		return "/"
+	}
+	return p
+}
+
+// oidcFrontendRedirect sends the browser back to the SPA. Values travel in the
+// URL fragment so tokens never reach server logs or Referer headers.
+func oidcFrontendRedirect(c *fiber.Ctx, values url.Values) error {
+	return c.Redirect(os.Getenv("FRONTEND_URL")+"/auth/callback#"+values.Encode(), fiber.StatusFound)
+}
+
+func oidcErrorRedirect(c *fiber.Ctx, code string) error {
+	return oidcFrontendRedirect(c, url.Values{"error": {code}})
+}
+
+// GET /api/gable/oidc/login?redirect=/play
+// Starts an authorization code + PKCE sign-in with the configured provider.
+func OIDCLogin(c *fiber.Ctx) error {
+	authURL, err := beginOIDCFlow(c, 0, safeRedirectPath(c.Query("redirect", "/")))
+	if errors.Is(err, errOIDCNotConfigured) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Single sign-on is not configured"})
+	}
+	if err != nil {
+		log.Printf("oidc login error: %v", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Identity provider unavailable"})
+	}
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// POST /api/gable/user/identities/link
+// Returns the provider URL that links an external identity to the caller.
+func LinkIdentity(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	authURL, err := beginOIDCFlow(c, userID, "/account")
+	if errors.Is(err, errOIDCNotConfigured) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Single sign-on is not configured"})
+	}
+	if err != nil {
+		log.Printf("oidc link error: %v", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Identity provider unavailable"})
+	}
+	return c.JSON(fiber.Map{"url": authURL})
+}
+
+// GET /api/gable/oidc/callback?code=...&state=...
+// Completes the flow: links the identity, or signs the user in (creating the
+// account on first sign-in) and hands the same tokens Login issues to the SPA.
+func OIDCCallback(c *fiber.Ctx) error {
+	if c.Query("error") != "" {
+		return oidcErrorRedirect(c, "provider_denied")
+	}
+	code := c.Query("code")
+	state := c.Query("state")
+	if code == "" || state == "" {
+		return oidcErrorRedirect(c, "invalid_request")
+	}
+	stateHash := auth.HashToken(state)
+	bound := subtle.ConstantTimeCompare([]byte(c.Cookies(oidcBrowserCookie)), []byte(stateHash)) == 1
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcBrowserCookie,
+		Path:     oidcCallbackPath,
+		Expires:  time.Unix(0, 0),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	if !bound {
+		return oidcErrorRedirect(c, "invalid_state")
+	}
+
+	var (
+		verifier     string
+		nonce        string
+		linkUserID   sql.NullInt32
+		redirectPath sql.NullString
+	)
+	err := database.DB.QueryRow(`
+		DELETE FROM oidc_login_states
+		WHERE state_hash = $1 AND expires_at > now()
+		RETURNING code_verifier, nonce, link_user_id, redirect_path
+	`, stateHash).Scan(&verifier, &nonce, &linkUserID, &redirectPath)
+	if err != nil {
+		return oidcErrorRedirect(c, "invalid_state")
+	}
+
+	p, err := getOIDCProvider(c.UserContext())
+	if err != nil {
+		log.Printf("oidc callback error: %v", err)
+		return oidcErrorRedirect(c, "provider_unavailable")
+	}
+
+	tok, err := p.Exchange(c.UserContext(), code, verifier, nonce)
+	if err != nil {
+		log.Printf("oidc exchange error: %v", err)
+		return oidcErrorRedirect(c, "exchange_failed")
+	}
+
+	if linkUserID.Valid {
+		var owner int
+		err := database.DB.QueryRow(`
+			INSERT INTO user_identities (user_id, issuer, subject, email)
+			VALUES ($1, $2, $3, NULLIF($4, ''))
+			ON CONFLICT (issuer, subject) DO UPDATE SET issuer = EXCLUDED.issuer
+			RETURNING user_id
+		`, linkUserID.Int32, tok.Issuer, tok.Subject, tok.Email).Scan(&owner)
+		if err != nil {
+			log.Printf("oidc link error: %v", err)
+			return oidcErrorRedirect(c, "link_failed")
+		}
+		if owner != int(linkUserID.Int32) {
+			return oidcErrorRedirect(c, "identity_in_use")
+		}
+		return oidcFrontendRedirect(c, url.Values{"linked": {"1"}, "redirect": {redirectPath.String}})
+	}
+
+	userID, email, err := resolveOIDCUser(tok)
+	if errors.Is(err, errOIDCEmailUnverified) {
+		return oidcErrorRedirect(c, "email_unverified")
+	}
+	if err != nil {
+		log.Printf("oidc sign-in error: %v", err)
+		return oidcErrorRedirect(c, "signin_failed")
+	}
+
+	resp, err := startSession(c, userID, email)
+	if err != nil {
+		log.Printf("Failed to start session: %v", err)
+		return oidcErrorRedirect(c, "signin_failed")
+	}
+
+	return oidcFrontendRedirect(c, url.Values{
+		"token":         {resp["token"].(string)},
+		"refresh_token": {resp["refresh_token"].(string)},
+		"expires_in":    {strconv.Itoa(resp["expires_in"].(int))},
+		"redirect":      {redirectPath.String},
+	})
+}
+
+// resolveOIDCUser maps a verified ID token to a users row: an existing link
+// wins; otherwise a provider-verified email links to (or creates) the account.
+// Linking to an unverified account strips its password and sessions first.
+func resolveOIDCUser(tok *oidc.IDToken) (int, string, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, "", err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var email string
+	err = tx.QueryRow(`
+		SELECT u.id, u.email
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.issuer = $1 AND i.subject = $2
+	`, tok.Issuer, tok.Subject).Scan(&userID, &email)
+	if err == nil {
+		if _, err := tx.Exec(`
+			UPDATE user_identities SET last_login_at = now()
+			WHERE issuer = $1 AND subject = $2
+		`, tok.Issuer, tok.Subject); err != nil {
+			return 0, "", err
+		}
+		return userID, email, tx.Commit()
+	}
+	if err != sql.ErrNoRows {
+		return 0, "", err
+	}
+
+	if tok.Email == "" || !tok.EmailVerified {
+		return 0, "", errOIDCEmailUnverified
+	}
+
+	tokEmail := strings.ToLower(strings.TrimSpace(tok.Email))
+	var verified bool
+	err = tx.QueryRow(
+		`SELECT id, email, verified FROM users WHERE lower(email) = $1 FOR UPDATE`, tokEmail,
+	).Scan(&userID, &email, &verified)
+	switch {
+	case err == nil && !verified:
+		// Whoever registered this unverified account never proved they own the
+		// address; the provider says the SSO user does. Drop the password and
+		// end any sessions so the registrant cannot keep a way in.
+		if _, err := tx.Exec(`
+			UPDATE users SET verified = true, password_hash = $2, has_password = false
+			WHERE id = $1
+		`, userID, unusablePasswordHash); err != nil {
+			return 0, "", err
+		}
+		if _, err := tx.Exec(`
+			UPDATE user_sessions
+			SET revoked_at = now(), revoked_reason = 'oidc_claimed'
+			WHERE user_id = $1 AND revoked_at IS NULL
+		`, userID); err != nil {
+			return 0, "", err
+		}
+	case err == nil:
+		// A verified account already proved ownership of the same address.
+	case err == sql.ErrNoRows:
+		email = tokEmail
+		err = tx.QueryRow(`
+			INSERT INTO users (email, password_hash, verified, has_password)
+			VALUES ($1, $2, true, false)
+			RETURNING id
+		`, email, unusablePasswordHash).Scan(&userID)
+		if err != nil {
+			return 0, "", err
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO user_stats (user_id, win_distribution)
+			VALUES ($1, '{}'::jsonb)
+		`, userID); err != nil {
+			return 0, "", err
+		}
+	default:
+		return 0, "", err
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO user_identities (user_id, issuer, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, now())
+	`, userID, tok.Issuer, tok.Subject, tokEmail); err != nil {
+		return 0, "", err
+	}
+
+	return userID, email, tx.Commit()
+}
+
+// GET /api/gable/user/identities
+func ListIdentities(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, issuer, COALESCE(email, ''), created_at, last_login_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list identities"})
+	}
+	defer rows.Close()
+
+	identities := make([]IdentityRow, 0)
+	for rows.Next() {
+		var i IdentityRow
+		if err := rows.Scan(&i.ID, &i.Issuer, &i.Email, &i.CreatedAt, &i.LastLoginAt); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read identities"})
+		}
+		identities = append(identities, i)
+	}
+
+	return c.JSON(identities)
+}
+
+// DELETE /api/gable/user/identities/:id
+// Refuses to remove the only way into an account without a password.
+func UnlinkIdentity(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	identityID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || identityID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid identity id"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start transaction"})
+	}
+	defer tx.Rollback()
+
+	var hasPassword bool
+	var identities int
+	err = tx.QueryRow(`SELECT has_password FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&hasPassword)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load account"})
+	}
+	err = tx.QueryRow(`SELECT COUNT(*) FROM user_identities WHERE user_id = $1`, userID).Scan(&identities)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load identities"})
+	}
+	if !hasPassword && identities <= 1 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Cannot unlink your only sign-in method"})
+	}
+
+	res, err := tx.Exec(`DELETE FROM user_identities WHERE id = $1 AND user_id = $2`, identityID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to unlink identity"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Identity not found"})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to unlink identity"})
+	}
+
+	return c.JSON(fiber.Map{"ok": true})
+}
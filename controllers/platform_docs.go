@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"gable-backend/internal/apidocs"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ---------------------------------------------------------------------------
+// GET /api/v1/openapi.json
+// ---------------------------------------------------------------------------
+func V1OpenAPISpec(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(apidocs.Spec)
+}
+
+// ---------------------------------------------------------------------------
+// GET /api/v1/docs
+// ---------------------------------------------------------------------------
+func V1Docs(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(apidocs.Page)
+}
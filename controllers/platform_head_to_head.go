@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"sort"
+
+	"gable-backend/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ---------------------------------------------------------------------------
+// Response types
+// ---------------------------------------------------------------------------
+
+type HeadToHeadSeries struct {
+	AWins       int `json:"a_wins"`
+	BWins       int `json:"b_wins"`
+	NoDecisions int `json:"no_decisions"` // bouts without a recorded winner
+}
+
+type HeadToHeadResponse struct {
+	A      WrestlerRef      `json:"a"`
+	B      WrestlerRef      `json:"b"`
+	Series HeadToHeadSeries `json:"series"`
+	Bouts  []WrestlerBout   `json:"bouts"` // from a's side, newest first
+}
+
+type CommonOpponentSide struct {
+	Wins   int            `json:"wins"`
+	Losses int            `json:"losses"`
+	Bouts  []WrestlerBout `json:"bouts"`
+}
+
+type CommonOpponent struct {
+	Opponent WrestlerRef        `json:"opponent"`
+	A        CommonOpponentSide `json:"a"`
+	B        CommonOpponentSide `json:"b"`
+}
+
+type CommonOpponentsResponse struct {
+	A         WrestlerRef      `json:"a"`
+	B         WrestlerRef      `json:"b"`
+	Opponents []CommonOpponent `json:"opponents"`
+}
+
+// headToHeadPair resolves the a and b query params. When done is true the
+// response (400, 404 or 500) has already been written.
+func headToHeadPair(c *fiber.Ctx) (a, b WrestlerRef, done bool, err error) {
+	refs := [2]*WrestlerRef{&a, &b}
+	for i, param := range []string{"a", "b"} {
+		ref := c.Query(param)
+		if ref == "" {
+			return a, b, true, c.Status(400).JSON(fiber.Map{"error": "a and b are required"})
+		}
+		r, err := resolveRef(wrestlerEntity, ref)
+		if errors.Is(err, errRefNotFound) {
+			return a, b, true, c.Status(404).JSON(fiber.Map{"error": "wrestler not found: " + ref})
+		}
+		if err == nil {
+			err = database.DB.QueryRow(
+				`SELECT id, full_name, slug FROM core.wrestler WHERE id = $1`, r.ID,
+			).Scan(&refs[i].ID, &refs[i].Name, &refs[i].Slug)
+		}
+		if err != nil {
+			log.Printf("platform_head_to_head error: %v", err)
+			return a, b, true, c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		}
+	}
+	if a.ID == b.ID {
+		return a, b, true, c.Status(400).JSON(fiber.Map{"error": "a and b must be different wrestlers"})
+	}
+	return a, b, false, nil
+}
+
+// ---------------------------------------------------------------------------
+// GET /api/v1/head-to-head
+// Query params: a, b (wrestler UUID or slug, required), source (ranking
+// source slug for the ranks shown)
+// Returns every bout between the two across all seasons, from a's side.
+// ---------------------------------------------------------------------------
+func V1GetHeadToHead(c *fiber.Ctx) error {
+	a, b, done, err := headToHeadPair(c)
+	if done {
+		return err
+	}
+
+	var f queryFilter
+	f.add("(b.wrestler_a_id = $? OR b.wrestler_b_id = $?)", a.ID)
+	f.add("opp.id = $?", b.ID)
+	bouts, err := queryWrestlerBouts(f, c.Query("source"))
+	if err != nil {
+		log.Printf("platform_head_to_head error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	out := HeadToHeadResponse{A: a, B: b, Bouts: bouts}
+	for _, bout := range bouts {
+		switch {
+		case bout.Result == nil:
+			out.Series.NoDecisions++
+		case *bout.Result == "W":
+			out.Series.AWins++
+		default:
+			out.Series.BWins++
+		}
+	}
+	return c.JSON(out)
+}
+
+// ---------------------------------------------------------------------------
+// GET /api/v1/head-to-head/common-opponents
+// Query params: a, b (wrestler UUID or slug, required), source (ranking
+// source slug for the ranks shown)
+// Lists wrestlers both have faced, other than each other, with each side's
+// results against them. Opponents are sorted by name.
+// ---------------------------------------------------------------------------
+func V1GetCommonOpponents(c *fiber.Ctx) error {
+	a, b, done, err := headToHeadPair(c)
+	if done {
+		return err
+	}
+
+	byOpponent := map[string]*CommonOpponent{}
+	for _, side := range []struct {
+		self, other string
+		pick        func(*CommonOpponent) *CommonOpponentSide
+	}{
+		{a.ID, b.ID, func(o *CommonOpponent) *CommonOpponentSide { return &o.A }},
+		{b.ID, a.ID, func(o *CommonOpponent) *CommonOpponentSide { return &o.B }},
+	} {
+		var f queryFilter
+		f.add("(b.wrestler_a_id = $? OR b.wrestler_b_id = $?)", side.self)
+		f.add(`opp.id <> $? AND opp.id IN (
+			SELECT CASE WHEN x.wrestler_a_id = $? THEN x.wrestler_b_id ELSE x.wrestler_a_id END
+			FROM core.bout x WHERE x.wrestler_a_id = $? OR x.wrestler_b_id = $?
+		)`, side.other)
+		bouts, err := queryWrestlerBouts(f, c.Query("source"))
+		if err != nil {
+			log.Printf("platform_head_to_head error: %v", err)
+			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		}
+		for _, bout := range bouts {
+			o, ok := byOpponent[bout.Opponent.WrestlerID]
+			if !ok {
+				o = &CommonOpponent{
+					Opponent: WrestlerRef{ID: bout.Opponent.WrestlerID, Name: bout.Opponent.Name, Slug: bout.Opponent.Slug},
+					A:        CommonOpponentSide{Bouts: []WrestlerBout{}},
+					B:        CommonOpponentSide{Bouts: []WrestlerBout{}},
+				}
+				byOpponent[bout.Opponent.WrestlerID] = o
+			}
+			s := side.pick(o)
+			s.Bouts = append(s.Bouts, bout)
+			if bout.Result != nil {
+				if *bout.Result == "W" {
+					s.Wins++
+				} else {
+					s.Losses++
+				}
+			}
+		}
+	}
+
+	out := CommonOpponentsResponse{A: a, B: b, Opponents: []CommonOpponent{}}
+	for _, o := range byOpponent {
+		out.Opponents = append(out.Opponents, *o)
+	}
+	sort.Slice(out.Opponents, func(i, j int) bool {
+		if out.Opponents[i].Opponent.Name != out.Opponents[j].Opponent.Name {
+			return out.Opponents[i].Opponent.Name < out.Opponents[j].Opponent.Name
+		}
+		return out.Opponents[i].Opponent.ID < out.Opponents[j].Opponent.ID
+	})
+	return c.JSON(out)
+}
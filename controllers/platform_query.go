@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"gable-backend/database"
+	"gable-backend/internal/pagination"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ---------------------------------------------------------------------------
+// Shared query building for /api/v1 collections
+// Every collection takes sort, cursor, limit (default 50, max 200) and
+// include_total, and answers {data, next_cursor[, total]}.
+// ---------------------------------------------------------------------------
+
+// queryFilter collects WHERE clauses and their positional arguments.
+type queryFilter struct {
+	where []string
+	args  []interface{}
+}
+
+// add appends clause with every "$?" bound to arg.
+func (f *queryFilter) add(clause string, arg interface{}) {
+	f.where = append(f.where, strings.ReplaceAll(clause, "$?", f.bind(arg)))
+}
+
+// bind appends arg and returns its placeholder.
+func (f *queryFilter) bind(arg interface{}) string {
+	f.args = append(f.args, arg)
+	return "$" + itoa(len(f.args))
+}
+
+func (f *queryFilter) clause() string {
+	if len(f.where) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(f.where, " AND ")
+}
+
+// pageParams parses the paging query params against spec. When done is
+// true the 400 has already been written.
+func pageParams(c *fiber.Ctx, spec pagination.Spec) (p pagination.Params, done bool, err error) {
+	p, err = spec.Parse(c.Query("sort"), c.Query("cursor"), c.Query("limit"), c.Query("include_total"))
+	switch {
+	case errors.Is(err, pagination.ErrSort):
+		return p, true, c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, pagination.ErrCursor):
+		return p, true, c.Status(400).JSON(fiber.Map{"error": "invalid cursor; cursors only continue the sort they came from"})
+	}
+	return p, false, nil
+}
+
+// pageQuery is a collection query before paging is applied.
+type pageQuery struct {
+	With    string // optional WITH clause
+	Columns string
+	From    string // FROM and JOINs
+	Filter  queryFilter
+}
+
+// fetchPage runs q for one page. scan returns the destinations for Columns
+// in order; the cursor key columns are appended after them.
+func fetchPage[T any](q pageQuery, p pagination.Params, scan func(*T) []interface{}) (pagination.Page[T], error) {
+	page := pagination.Page[T]{Data: []T{}}
+	f := queryFilter{
+		where: append([]string(nil), q.Filter.where...),
+		args:  append([]interface{}(nil), q.Filter.args...),
+	}
+
+	if p.Total {
+		var total int
+		err := database.DB.QueryRow(q.With+` SELECT COUNT(*) `+q.From+` `+f.clause(), f.args...).Scan(&total)
+		if err != nil {
+			return page, err
+		}
+		page.Total = &total
+	}
+
+	if keyset := p.Keyset(f.bind); keyset != "" {
+		f.where = append(f.where, keyset)
+	}
+	rows, err := database.DB.Query(q.With+`
+		SELECT `+q.Columns+`, `+p.KeyColumns()+`
+		`+q.From+`
+		`+f.clause()+`
+		ORDER BY `+p.OrderBy()+`
+		LIMIT `+itoa(p.FetchLimit()), f.args...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	var keys [][]string
+	for rows.Next() {
+		var item T
+		key, keyDest := p.KeyDest()
+		if err := rows.Scan(append(scan(&item), keyDest...)...); err != nil {
+			return page, err
+		}
+		page.Data = append(page.Data, item)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+
+	n, next := p.Next(keys)
+	page.Data, page.NextCursor = page.Data[:n], next
+	return page, nil
+}
@@ -1,12 +1,12 @@
 package controllers
 
 import (
-	"database/sql"
+	"fmt"
 	"log"
 	"strconv"
-	"strings"
 
-	"gable-backend/database"
+	"gable-backend/internal/cache"
+	"gable-backend/internal/pagination"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -40,151 +40,162 @@ type RankingHistoryEntry struct {
 	SeasonYear   int    `json:"season_year"`
 }
 
+var rankingSort = pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "date", Columns: []pagination.Column{
+			pagination.Asc("rs.snapshot_date"), pagination.Desc("wc.sort_order"), pagination.Desc("re.rank"),
+		}},
+		{Name: "weight", Columns: []pagination.Column{
+			pagination.Asc("wc.sort_order"), pagination.Desc("rs.snapshot_date"), pagination.Asc("re.rank"),
+		}},
+		{Name: "rank", Columns: []pagination.Column{
+			pagination.Asc("re.rank"), pagination.Desc("rs.snapshot_date"), pagination.Asc("wc.sort_order"),
+		}},
+	},
+	Default: "-date",
+	Unique:  []string{"re.id"},
+}
+
 // ---------------------------------------------------------------------------
 // GET /api/v1/rankings
-// Query params: season (year), source (slug), date (YYYY-MM-DD), weight_class
-// Returns all published ranking entries matching the filters.
+// Query params: season (year), source (slug), date (YYYY-MM-DD), weight_class,
+// sort (date, weight, rank; default -date), cursor, limit, include_total
+// Returns published ranking entries matching the filters.
 // ---------------------------------------------------------------------------
 func V1GetRankings(c *fiber.Ctx) error {
-	args := []interface{}{}
-	where := []string{"rs.status = 'published'"}
-	i := 1
-
-	if s := c.Query("season"); s != "" {
-		args = append(args, s)
-		where = append(where, "se.year = $"+itoa(i))
-		i++
+	p, done, err := pageParams(c, rankingSort)
+	if done {
+		return err
 	}
-	if src := c.Query("source"); src != "" {
-		args = append(args, src)
-		where = append(where, "src.slug = $"+itoa(i))
-		i++
+
+	season := c.Query("season")
+	if y, err := strconv.Atoi(season); err == nil {
+		season = strconv.Itoa(y)
+	}
+	source, date, weight := c.Query("source"), c.Query("date"), c.Query("weight_class")
+
+	f := queryFilter{where: []string{"rs.status = 'published'"}}
+	if season != "" {
+		f.add("se.year = $?", season)
 	}
-	if d := c.Query("date"); d != "" {
-		args = append(args, d)
-		where = append(where, "rs.snapshot_date = $"+itoa(i))
-		i++
+	if source != "" {
+		f.add("src.slug = $?", source)
 	}
-	if wc := c.Query("weight_class"); wc != "" {
-		args = append(args, wc)
-		where = append(where, "wc.label = $"+itoa(i))
-		i++
+	if date != "" {
+		f.add("rs.snapshot_date = $?", date)
+	}
+	if weight != "" {
+		f.add("wc.label = $?", weight)
 	}
 
-	whereClause := "WHERE " + strings.Join(where, " AND ")
+	load := func() (pagination.Page[RankingEntryResponse], error) {
+		return fetchPage(pageQuery{
+			Columns: `
+				re.rank, re.previous_rank,
+				w.id, w.full_name, w.slug,
+				COALESCE(sc.name, ''), COALESCE(sc.slug, ''),
+				wc.label,
+				src.name, src.slug,
+				rs.snapshot_date::TEXT,
+				se.year`,
+			From: `
+				FROM core.ranking_entry re
+				JOIN core.ranking_snapshot rs ON rs.id = re.snapshot_id
+				JOIN core.ranking_source src ON src.id = rs.source_id
+				JOIN core.wrestler w ON w.id = re.wrestler_id
+				JOIN core.weight_class wc ON wc.id = rs.weight_class_id
+				JOIN core.season se ON se.id = rs.season_id
+				LEFT JOIN core.wrestler_season ws ON ws.wrestler_id = w.id AND ws.season_id = rs.season_id
+				LEFT JOIN core.school sc ON sc.id = ws.school_id`,
+			Filter: f,
+		}, p, func(e *RankingEntryResponse) []interface{} {
+			return []interface{}{
+				&e.Rank, &e.PreviousRank,
+				&e.WrestlerID, &e.WrestlerName, &e.WrestlerSlug,
+				&e.School, &e.SchoolSlug,
+				&e.WeightClass,
+				&e.SourceName, &e.SourceSlug,
+				&e.SnapshotDate, &e.SeasonYear,
+			}
+		})
+	}
 
-	rows, err := database.DB.Query(`
-		SELECT
-			re.rank, re.previous_rank,
-			w.id, w.full_name, w.slug,
-			sc.name, sc.slug,
-			wc.label,
-			src.name, src.slug,
-			rs.snapshot_date::TEXT,
-			se.year
-		FROM core.ranking_entry re
-		JOIN core.ranking_snapshot rs ON rs.id = re.snapshot_id
-		JOIN core.ranking_source src ON src.id = rs.source_id
-		JOIN core.wrestler w ON w.id = re.wrestler_id
-		JOIN core.weight_class wc ON wc.id = rs.weight_class_id
-		JOIN core.season se ON se.id = rs.season_id
-		LEFT JOIN core.wrestler_season ws ON ws.wrestler_id = w.id AND ws.season_id = rs.season_id
-		LEFT JOIN core.school sc ON sc.id = ws.school_id
-		`+whereClause+`
-		ORDER BY se.year DESC, rs.snapshot_date DESC, wc.sort_order, re.rank
-	`, args...)
+	// First pages are cached by their parsed filters; publishing a snapshot
+	// clears them. Later pages are rarer and keyed by cursor, so they skip
+	// the cache.
+	var out pagination.Page[RankingEntryResponse]
+	if p.First() {
+		key := fmt.Sprintf("v1:rankings:%q", []string{
+			season, source, date, weight, p.Sort, strconv.Itoa(p.Limit), strconv.FormatBool(p.Total),
+		})
+		out, err = cache.Fetch(cache.Default, key, []string{cache.TopicRankings, cache.TopicCore}, load)
+	} else {
+		out, err = load()
+	}
 	if err != nil {
 		log.Printf("platform_rankings error: %v", err)
 		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
 	}
-	defer rows.Close()
-
-	entries := []RankingEntryResponse{}
-	for rows.Next() {
-		var e RankingEntryResponse
-		var school, schoolSlug sql.NullString
-		if err := rows.Scan(
-			&e.Rank, &e.PreviousRank,
-			&e.WrestlerID, &e.WrestlerName, &e.WrestlerSlug,
-			&school, &schoolSlug,
-			&e.WeightClass,
-			&e.SourceName, &e.SourceSlug,
-			&e.SnapshotDate, &e.SeasonYear,
-		); err != nil {
-			log.Printf("platform_rankings error: %v", err)
-			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
-		}
-		if school.Valid {
-			e.School = school.String
-		}
-		if schoolSlug.Valid {
-			e.SchoolSlug = schoolSlug.String
-		}
-		entries = append(entries, e)
-	}
+	return c.JSON(out)
+}
 
-	return c.JSON(entries)
+var rankingHistorySort = pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "date", Columns: []pagination.Column{pagination.Asc("rs.snapshot_date"), pagination.Desc("src.name")}},
+		{Name: "rank", Columns: []pagination.Column{pagination.Asc("re.rank"), pagination.Desc("rs.snapshot_date")}},
+	},
+	Default: "-date",
+	Unique:  []string{"re.id"},
 }
 
 // ---------------------------------------------------------------------------
-// GET /api/v1/rankings/history/:wrestler_slug
-// Returns every published ranking entry for this wrestler across all sources
-// and seasons, most recent first.
+// GET /api/v1/rankings/history/:wrestler
+// :wrestler is the wrestler's UUID or slug; a retired slug redirects (301).
+// Query params: sort (date, rank; default -date), cursor, limit,
+// include_total
+// Returns published ranking entries for this wrestler across all sources
+// and seasons.
 // ---------------------------------------------------------------------------
 func V1GetWrestlerRankingHistory(c *fiber.Ctx) error {
-	wrestlerID := c.Params("wrestler_id")
-
-	// Confirm wrestler exists
-	var exists bool
-	err := database.DB.QueryRow(
-		`SELECT EXISTS(SELECT 1 FROM core.wrestler WHERE id = $1)`, wrestlerID,
-	).Scan(&exists)
-	if err != nil {
-		log.Printf("platform_rankings error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	ref, done, err := resolvePathRef(c, wrestlerEntity, "wrestler")
+	if done {
+		return err
 	}
-	if !exists {
-		return c.Status(404).JSON(fiber.Map{"error": "wrestler not found"})
+	p, done, err := pageParams(c, rankingHistorySort)
+	if done {
+		return err
 	}
 
-	rows, err := database.DB.Query(`
-		SELECT
+	f := queryFilter{where: []string{"rs.status = 'published'"}}
+	f.add("re.wrestler_id = $?", ref.ID)
+
+	out, err := fetchPage(pageQuery{
+		Columns: `
 			re.rank, re.previous_rank,
 			rs.snapshot_date::TEXT,
 			wc.label,
 			src.name, src.slug,
-			se.year
-		FROM core.ranking_entry re
-		JOIN core.ranking_snapshot rs ON rs.id = re.snapshot_id
-		JOIN core.ranking_source src ON src.id = rs.source_id
-		JOIN core.weight_class wc ON wc.id = rs.weight_class_id
-		JOIN core.season se ON se.id = rs.season_id
-		WHERE re.wrestler_id = $1
-		  AND rs.status = 'published'
-		ORDER BY rs.snapshot_date DESC, src.name
-	`, wrestlerID)
-	if err != nil {
-		log.Printf("platform_rankings error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
-	}
-	defer rows.Close()
-
-	history := []RankingHistoryEntry{}
-	for rows.Next() {
-		var e RankingHistoryEntry
-		if err := rows.Scan(
+			se.year`,
+		From: `
+			FROM core.ranking_entry re
+			JOIN core.ranking_snapshot rs ON rs.id = re.snapshot_id
+			JOIN core.ranking_source src ON src.id = rs.source_id
+			JOIN core.weight_class wc ON wc.id = rs.weight_class_id
+			JOIN core.season se ON se.id = rs.season_id`,
+		Filter: f,
+	}, p, func(e *RankingHistoryEntry) []interface{} {
+		return []interface{}{
 			&e.Rank, &e.PreviousRank,
 			&e.SnapshotDate, &e.WeightClass,
 			&e.SourceName, &e.SourceSlug,
 			&e.SeasonYear,
-		); err != nil {
-			log.Printf("platform_rankings error: %v", err)
-			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
 		}
-		history = append(history, e)
+	})
+	if err != nil {
+		log.Printf("platform_rankings error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
 	}
-
-	return c.JSON(history)
+	return c.JSON(out)
 }
 
 // itoa converts an int to its decimal string representation.
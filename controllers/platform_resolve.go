@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+
+	"gable-backend/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ---------------------------------------------------------------------------
+// Identifier resolution
+// Wrestler, school and conference routes accept either the UUID or the slug.
+// Retired slugs (core.slug_history) resolve to the current one so handlers
+// can answer with a 301 instead of a 404.
+// ---------------------------------------------------------------------------
+
+type entityKind struct {
+	name  string // core.slug_history.entity_type
+	table string
+}
+
+var (
+	wrestlerEntity   = entityKind{"wrestler", "core.wrestler"}
+	schoolEntity     = entityKind{"school", "core.school"}
+	conferenceEntity = entityKind{"conference", "core.conference"}
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var errRefNotFound = errors.New("not found")
+
+type resolvedRef struct {
+	ID    string
+	Slug  string // current slug
+	Moved bool   // ref was a retired slug
+}
+
+// resolveRef looks ref up as a UUID, a current slug, then a retired slug.
+func resolveRef(kind entityKind, ref string) (resolvedRef, error) {
+	var r resolvedRef
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return r, errRefNotFound
+	}
+
+	column := "slug"
+	if uuidPattern.MatchString(ref) {
+		column = "id"
+	}
+	err := database.DB.QueryRow(
+		`SELECT id, slug FROM `+kind.table+` WHERE `+column+` = $1`, ref,
+	).Scan(&r.ID, &r.Slug)
+	if err == nil {
+		return r, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) || column == "id" {
+		return r, notFoundOr(err)
+	}
+
+	err = database.DB.QueryRow(`
+		SELECT t.id, t.slug
+		FROM core.slug_history h
+		JOIN `+kind.table+` t ON t.id = h.entity_id
+		WHERE h.entity_type = $1 AND h.old_slug = $2
+	`, kind.name, ref).Scan(&r.ID, &r.Slug)
+	if err != nil {
+		return r, notFoundOr(err)
+	}
+	r.Moved = true
+	return r, nil
+}
+
+func notFoundOr(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return errRefNotFound
+	}
+	return err
+}
+
+// redirectToCanonical answers a request made with a retired slug with a 301
+// to the same URL using the current slug.
+func redirectToCanonical(c *fiber.Ctx, param, slug string) error {
+	old := c.Params(param)
+	segments := strings.Split(c.Path(), "/")
+	for i, s := range segments {
+		if s == old {
+			segments[i] = slug
+			break
+		}
+	}
+	location := strings.Join(segments, "/")
+	if q := string(c.Request().URI().QueryString()); q != "" {
+		location += "?" + q
+	}
+	return c.Redirect(location, fiber.StatusMovedPermanently)
+}
+
+// resolvePathRef resolves the route parameter param. When it returns
+// done=true the response (404, 500 or redirect) has already been written.
+func resolvePathRef(c *fiber.Ctx, kind entityKind, param string) (r resolvedRef, done bool, err error) {
+	r, err = resolveRef(kind, c.Params(param))
+	switch {
+	case errors.Is(err, errRefNotFound):
+		return r, true, c.Status(404).JSON(fiber.Map{"error": kind.name + " not found"})
+	case err != nil:
+		log.Printf("platform_resolve error: %v", err)
+		return r, true, c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	case r.Moved:
+		return r, true, redirectToCanonical(c, param, r.Slug)
+	}
+	return r, false, nil
+}
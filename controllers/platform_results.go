@@ -0,0 +1,569 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/internal/pagination"
+	"gable-backend/internal/results"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+// ---------------------------------------------------------------------------
+// Response types
+// ---------------------------------------------------------------------------
+
+type BoutCompetitor struct {
+	WrestlerID string  `json:"wrestler_id"`
+	Name       string  `json:"name"`
+	Slug       string  `json:"slug"`
+	School     *string `json:"school"`
+	SchoolSlug *string `json:"school_slug"`
+	Score      *int    `json:"score"`
+	Winner     bool    `json:"winner"`
+}
+
+type BoutEvent struct {
+	ID   *string `json:"id"`
+	Name *string `json:"name"`
+	Date *string `json:"date"`
+	Type *string `json:"type"`
+}
+
+type BoutSource struct {
+	Name    string  `json:"name"`
+	MatchID *string `json:"match_id"`
+}
+
+type BoutResponse struct {
+	ID           string         `json:"id"`
+	SeasonYear   int            `json:"season_year"`
+	Event        BoutEvent      `json:"event"`
+	WeightClass  *string        `json:"weight_class"`
+	WrestlerA    BoutCompetitor `json:"wrestler_a"`
+	WrestlerB    BoutCompetitor `json:"wrestler_b"`
+	ResultMethod *string        `json:"result_method"` // canonical; null when unrecognised
+	ResultRaw    *string        `json:"result_method_raw"`
+	MatchTime    *string        `json:"match_time"`
+	Source       BoutSource     `json:"source"`
+
+	winnerID string
+}
+
+type DualTeam struct {
+	School     string `json:"school"`
+	SchoolSlug string `json:"school_slug"`
+	Score      int    `json:"score"`
+	BoutsWon   int    `json:"bouts_won"`
+}
+
+type DualResponse struct {
+	EventID    string     `json:"event_id"`
+	Name       string     `json:"name"`
+	Date       *string    `json:"date"`
+	SeasonYear int        `json:"season_year"`
+	Teams      []DualTeam `json:"teams"`
+	Bouts      int        `json:"bouts"`
+	Source     BoutSource `json:"source"`
+}
+
+// eventDate orders undated events after dated ones when newest first.
+const eventDate = "COALESCE(ev.event_date, DATE '0001-01-01')"
+
+var boutSort = pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "date", Columns: []pagination.Column{
+			pagination.Asc(eventDate), pagination.Desc("COALESCE(ev.name, '')"), pagination.Desc("COALESCE(wc.sort_order, 0)"),
+		}},
+		{Name: "weight", Columns: []pagination.Column{
+			pagination.Asc("COALESCE(wc.sort_order, 0)"), pagination.Desc(eventDate),
+		}},
+	},
+	Default: "-date",
+	Unique:  []string{"b.id"},
+}
+
+var dualSort = pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "date", Columns: []pagination.Column{pagination.Asc(eventDate), pagination.Desc("ev.name")}},
+		{Name: "name", Columns: []pagination.Column{pagination.Asc("ev.name")}},
+	},
+	Default: "-date",
+	Unique:  []string{"ev.id"},
+}
+
+// boutFrom joins a bout to its event, weight and both wrestlers. Schools are
+// the wrestler's school for the bout's season.
+const boutFrom = `
+	FROM core.bout b
+	JOIN core.season se ON se.id = b.season_id
+	LEFT JOIN core.event ev ON ev.id = b.event_id
+	LEFT JOIN core.weight_class wc ON wc.id = b.weight_class_id
+	JOIN core.wrestler wa ON wa.id = b.wrestler_a_id
+	JOIN core.wrestler wb ON wb.id = b.wrestler_b_id
+	LEFT JOIN core.wrestler_season wsa ON wsa.wrestler_id = wa.id AND wsa.season_id = b.season_id
+	LEFT JOIN core.school sa ON sa.id = wsa.school_id
+	LEFT JOIN core.wrestler_season wsb ON wsb.wrestler_id = wb.id AND wsb.season_id = b.season_id
+	LEFT JOIN core.school sb ON sb.id = wsb.school_id
+`
+
+// dateRange adds date_from / date_to (inclusive, YYYY-MM-DD) against column.
+func (f *queryFilter) dateRange(c *fiber.Ctx, column string) error {
+	for _, p := range []struct{ param, op string }{{"date_from", ">="}, {"date_to", "<="}} {
+		v := c.Query(p.param)
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return fmt.Errorf("%s must be YYYY-MM-DD", p.param)
+		}
+		f.add(column+" "+p.op+" $?::DATE", v)
+	}
+	return nil
+}
+
+// normalizedMethod maps a stored result method to its canonical form, or nil
+// when it is missing or unrecognised.
+func normalizedMethod(raw *string) *string {
+	if raw == nil {
+		return nil
+	}
+	if m := results.Normalize(*raw); m != "" {
+		return &m
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// GET /api/v1/results/bouts
+// Query params: season (year int), event (UUID), school (UUID or slug),
+// weight_class, wrestler (UUID or slug), method (DEC, MD, TF, FALL, FOR, INJ,
+// DQ, NC), date_from, date_to (YYYY-MM-DD), sort (date, weight; default
+// -date), cursor, limit, include_total
+// ---------------------------------------------------------------------------
+func V1GetBouts(c *fiber.Ctx) error {
+	p, done, err := pageParams(c, boutSort)
+	if done {
+		return err
+	}
+	empty := pagination.Page[BoutResponse]{Data: []BoutResponse{}}
+
+	var f queryFilter
+	if s := c.Query("season"); s != "" {
+		f.add("se.year = $?", s)
+	}
+	if ev := c.Query("event"); ev != "" {
+		if !uuidPattern.MatchString(ev) {
+			return c.JSON(empty)
+		}
+		f.add("b.event_id = $?", ev)
+	}
+	if wc := c.Query("weight_class"); wc != "" {
+		f.add("wc.label = $?", wc)
+	}
+	if m := c.Query("method"); m != "" {
+		method, ok := results.ParseMethod(m)
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "unknown result method"})
+		}
+		f.add(fmt.Sprintf(results.SquashSQL, "b.result_method")+" = ANY($?)", pq.Array(results.Aliases(method)))
+	}
+	if err := f.dateRange(c, "ev.event_date"); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, p := range []struct {
+		param  string
+		kind   entityKind
+		clause string
+	}{
+		{"school", schoolEntity, "(sa.id = $? OR sb.id = $?)"},
+		{"wrestler", wrestlerEntity, "(b.wrestler_a_id = $? OR b.wrestler_b_id = $?)"},
+	} {
+		ref := c.Query(p.param)
+		if ref == "" {
+			continue
+		}
+		r, err := resolveRef(p.kind, ref)
+		if errors.Is(err, errRefNotFound) {
+			return c.JSON(empty)
+		}
+		if err != nil {
+			log.Printf("platform_results error: %v", err)
+			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		}
+		f.add(p.clause, r.ID)
+	}
+
+	out, err := fetchPage(pageQuery{
+		Columns: `
+			b.id, se.year,
+			ev.id, ev.name, ev.event_date::TEXT, ev.event_type,
+			wc.label,
+			wa.id, wa.full_name, wa.slug, sa.name, sa.slug, b.score_a,
+			wb.id, wb.full_name, wb.slug, sb.name, sb.slug, b.score_b,
+			COALESCE(b.winner_id::TEXT, ''), b.result_method, b.match_time,
+			b.source_name, b.source_match_id`,
+		From:   boutFrom,
+		Filter: f,
+	}, p, func(b *BoutResponse) []interface{} {
+		return []interface{}{
+			&b.ID, &b.SeasonYear,
+			&b.Event.ID, &b.Event.Name, &b.Event.Date, &b.Event.Type,
+			&b.WeightClass,
+			&b.WrestlerA.WrestlerID, &b.WrestlerA.Name, &b.WrestlerA.Slug, &b.WrestlerA.School, &b.WrestlerA.SchoolSlug, &b.WrestlerA.Score,
+			&b.WrestlerB.WrestlerID, &b.WrestlerB.Name, &b.WrestlerB.Slug, &b.WrestlerB.School, &b.WrestlerB.SchoolSlug, &b.WrestlerB.Score,
+			&b.winnerID, &b.ResultRaw, &b.MatchTime,
+			&b.Source.Name, &b.Source.MatchID,
+		}
+	})
+	if err != nil {
+		log.Printf("platform_results error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+	for i := range out.Data {
+		b := &out.Data[i]
+		b.WrestlerA.Winner = b.winnerID == b.WrestlerA.WrestlerID
+		b.WrestlerB.Winner = b.winnerID == b.WrestlerB.WrestlerID
+		b.ResultMethod = normalizedMethod(b.ResultRaw)
+	}
+	return c.JSON(out)
+}
+
+// ---------------------------------------------------------------------------
+// GET /api/v1/results/duals
+// Query params: season (year int), school (UUID or slug), date_from,
+// date_to (YYYY-MM-DD), sort (date, name; default -date), cursor, limit,
+// include_total
+// Team scores are computed from the bouts: 3 decision, 4 major, 5 tech fall,
+// 6 fall, forfeit, default or disqualification.
+// ---------------------------------------------------------------------------
+func V1GetDuals(c *fiber.Ctx) error {
+	p, done, err := pageParams(c, dualSort)
+	if done {
+		return err
+	}
+
+	f := queryFilter{where: []string{"lower(ev.event_type) = 'dual'"}}
+	if s := c.Query("season"); s != "" {
+		f.add("se.year = $?", s)
+	}
+	if err := f.dateRange(c, "ev.event_date"); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if ref := c.Query("school"); ref != "" {
+		r, err := resolveRef(schoolEntity, ref)
+		if errors.Is(err, errRefNotFound) {
+			return c.JSON(pagination.Page[DualResponse]{Data: []DualResponse{}})
+		}
+		if err != nil {
+			log.Printf("platform_results error: %v", err)
+			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		}
+		f.add(`EXISTS (
+			SELECT 1 FROM core.bout b
+			JOIN core.wrestler_season ws ON ws.season_id = b.season_id
+			 AND ws.wrestler_id IN (b.wrestler_a_id, b.wrestler_b_id)
+			WHERE b.event_id = ev.id AND ws.school_id = $?
+		)`, r.ID)
+	}
+
+	out, err := fetchPage(pageQuery{
+		Columns: `ev.id, ev.name, ev.event_date::TEXT, se.year, ev.source_name, ev.external_id`,
+		From: `
+			FROM core.event ev
+			JOIN core.season se ON se.id = ev.season_id`,
+		Filter: f,
+	}, p, func(d *DualResponse) []interface{} {
+		d.Teams = []DualTeam{}
+		return []interface{}{&d.EventID, &d.Name, &d.Date, &d.SeasonYear, &d.Source.Name, &d.Source.MatchID}
+	})
+	if err == nil {
+		err = scoreDuals(out.Data)
+	}
+	if err != nil {
+		log.Printf("platform_results error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+	return c.JSON(out)
+}
+
+// scoreDuals fills in the bout count and team scores for a page of duals.
+func scoreDuals(duals []DualResponse) error {
+	if len(duals) == 0 {
+		return nil
+	}
+	index := map[string]int{}
+	ids := make([]string, len(duals))
+	for i, d := range duals {
+		index[d.EventID] = i
+		ids[i] = d.EventID
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT b.event_id, sa.id, sa.name, sa.slug, sb.id, sb.name, sb.slug,
+		       b.winner_id = b.wrestler_a_id, COALESCE(b.result_method, '')
+		`+boutFrom+`
+		WHERE b.event_id = ANY($1::UUID[])
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	bouts := map[string][]results.DualBout{}
+	schools := map[string]SchoolRef{}
+	for rows.Next() {
+		var (
+			eventID, method   string
+			aWon              sql.NullBool
+			aID, aName, aSlug sql.NullString
+			bID, bName, bSlug sql.NullString
+		)
+		if err := rows.Scan(&eventID, &aID, &aName, &aSlug, &bID, &bName, &bSlug, &aWon, &method); err != nil {
+			return err
+		}
+		schools[aID.String] = SchoolRef{Name: aName.String, Slug: aSlug.String}
+		schools[bID.String] = SchoolRef{Name: bName.String, Slug: bSlug.String}
+		duals[index[eventID]].Bouts++
+		if !aWon.Valid {
+			continue // no winner recorded
+		}
+		bouts[eventID] = append(bouts[eventID], results.DualBout{
+			TeamA:  aID.String,
+			TeamB:  bID.String,
+			AWon:   aWon.Bool,
+			Method: results.Normalize(method),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for eventID, i := range index {
+		for _, t := range results.ScoreDual(bouts[eventID]) {
+			s := schools[t.Team]
+			duals[i].Teams = append(duals[i].Teams, DualTeam{
+				School:     s.Name,
+				SchoolSlug: s.Slug,
+				Score:      t.Points,
+				BoutsWon:   t.BoutsWon,
+			})
+		}
+	}
+	return nil
+}
+
+type BoutOpponent struct {
+	WrestlerID string  `json:"wrestler_id"`
+	Name       string  `json:"name"`
+	Slug       string  `json:"slug"`
+	School     *string `json:"school"`
+	SchoolSlug *string `json:"school_slug"`
+	Rank       *int    `json:"rank"`        // latest published rank on or before the bout
+	RankSource *string `json:"rank_source"` // ranking source slug
+}
+
+type WrestlerBout struct {
+	BoutID        string       `json:"bout_id"`
+	SeasonYear    int          `json:"season_year"`
+	Event         BoutEvent    `json:"event"`
+	WeightClass   *string      `json:"weight_class"`
+	Opponent      BoutOpponent `json:"opponent"`
+	Result        *string      `json:"result"` // "W", "L", or null when no winner is recorded
+	ResultMethod  *string      `json:"result_method"`
+	ResultRaw     *string      `json:"result_method_raw"`
+	Score         *int         `json:"score"`
+	OpponentScore *int         `json:"opponent_score"`
+	MatchTime     *string      `json:"match_time"`
+	Source        BoutSource   `json:"source"`
+}
+
+type SeasonRecord struct {
+	SeasonYear int `json:"season_year"`
+	Wins       int `json:"wins"`
+	Losses     int `json:"losses"`
+}
+
+type WrestlerResults struct {
+	Wrestler WrestlerRef    `json:"wrestler"`
+	Summary  []SeasonRecord `json:"summary"`
+	pagination.Page[WrestlerBout]
+}
+
+var wrestlerBoutSort = pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "date", Columns: []pagination.Column{pagination.Asc(eventDate)}},
+	},
+	Default: "-date",
+	Unique:  []string{"b.id"},
+}
+
+// wrestlerBoutFrom joins a wrestler's bouts from their side; $1 is the
+// wrestler id.
+const wrestlerBoutFrom = `
+	FROM core.bout b
+	JOIN core.season se ON se.id = b.season_id
+	LEFT JOIN core.event ev ON ev.id = b.event_id
+	LEFT JOIN core.weight_class wc ON wc.id = b.weight_class_id
+	JOIN core.wrestler opp ON opp.id = CASE WHEN b.wrestler_a_id = $1 THEN b.wrestler_b_id ELSE b.wrestler_a_id END
+	LEFT JOIN core.wrestler_season ows ON ows.wrestler_id = opp.id AND ows.season_id = b.season_id
+	LEFT JOIN core.school osc ON osc.id = ows.school_id
+`
+
+// wrestlerBoutQuery selects bouts matching f from the side of the wrestler
+// in $1. source limits opponent ranks to one ranking source.
+func wrestlerBoutQuery(f queryFilter, source string) pageQuery {
+	f = queryFilter{
+		where: append([]string(nil), f.where...),
+		args:  append([]interface{}(nil), f.args...),
+	}
+	sourceClause := ""
+	if source != "" {
+		sourceClause = "AND src.slug = " + f.bind(source)
+	}
+	return pageQuery{
+		Columns: `
+			b.id, se.year,
+			ev.id, ev.name, ev.event_date::TEXT, ev.event_type,
+			wc.label,
+			opp.id, opp.full_name, opp.slug, osc.name, osc.slug,
+			orank.rank, orank.slug,
+			CASE WHEN b.winner_id IS NULL THEN NULL
+			     WHEN b.winner_id = $1 THEN 'W' ELSE 'L' END,
+			b.result_method,
+			CASE WHEN b.wrestler_a_id = $1 THEN b.score_a ELSE b.score_b END,
+			CASE WHEN b.wrestler_a_id = $1 THEN b.score_b ELSE b.score_a END,
+			b.match_time, b.source_name, b.source_match_id`,
+		From: wrestlerBoutFrom + `
+			LEFT JOIN LATERAL (
+				SELECT re.rank, src.slug
+				FROM core.ranking_entry re
+				JOIN core.ranking_snapshot rs ON rs.id = re.snapshot_id
+				JOIN core.ranking_source src ON src.id = rs.source_id
+				WHERE re.wrestler_id = opp.id
+				  AND rs.status = 'published'
+				  AND rs.season_id = b.season_id
+				  AND (ev.event_date IS NULL OR rs.snapshot_date <= ev.event_date)
+				  ` + sourceClause + `
+				ORDER BY rs.snapshot_date DESC, src.name
+				LIMIT 1
+			) orank ON true`,
+		Filter: f,
+	}
+}
+
+func scanWrestlerBout(b *WrestlerBout) []interface{} {
+	return []interface{}{
+		&b.BoutID, &b.SeasonYear,
+		&b.Event.ID, &b.Event.Name, &b.Event.Date, &b.Event.Type,
+		&b.WeightClass,
+		&b.Opponent.WrestlerID, &b.Opponent.Name, &b.Opponent.Slug, &b.Opponent.School, &b.Opponent.SchoolSlug,
+		&b.Opponent.Rank, &b.Opponent.RankSource,
+		&b.Result, &b.ResultRaw, &b.Score, &b.OpponentScore,
+		&b.MatchTime, &b.Source.Name, &b.Source.MatchID,
+	}
+}
+
+// queryWrestlerBouts returns every bout matching f from the side of the
+// wrestler in $1, newest first.
+func queryWrestlerBouts(f queryFilter, source string) ([]WrestlerBout, error) {
+	q := wrestlerBoutQuery(f, source)
+	rows, err := database.DB.Query(`
+		SELECT `+q.Columns+`
+		`+q.From+`
+		`+q.Filter.clause()+`
+		ORDER BY `+eventDate+` DESC, b.id`, q.Filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bouts := []WrestlerBout{}
+	for rows.Next() {
+		var b WrestlerBout
+		if err := rows.Scan(scanWrestlerBout(&b)...); err != nil {
+			return nil, err
+		}
+		b.ResultMethod = normalizedMethod(b.ResultRaw)
+		bouts = append(bouts, b)
+	}
+	return bouts, rows.Err()
+}
+
+// ---------------------------------------------------------------------------
+// GET /api/v1/wrestlers/:id/results
+// :id is the wrestler's UUID or slug; a retired slug redirects (301).
+// Query params: season (year int), event_type (e.g. dual), source (ranking
+// source slug for opponent ranks), sort (date; default -date), cursor,
+// limit, include_total
+// The summary covers every bout matching the filters, not just the page.
+// ---------------------------------------------------------------------------
+func V1GetWrestlerResults(c *fiber.Ctx) error {
+	ref, done, err := resolvePathRef(c, wrestlerEntity, "id")
+	if done {
+		return err
+	}
+	p, done, err := pageParams(c, wrestlerBoutSort)
+	if done {
+		return err
+	}
+	failed := func(err error) error {
+		log.Printf("platform_results error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	out := WrestlerResults{Summary: []SeasonRecord{}}
+	if err := database.DB.QueryRow(
+		`SELECT id, full_name, slug FROM core.wrestler WHERE id = $1`, ref.ID,
+	).Scan(&out.Wrestler.ID, &out.Wrestler.Name, &out.Wrestler.Slug); err != nil {
+		return failed(err)
+	}
+
+	var f queryFilter
+	f.add("(b.wrestler_a_id = $? OR b.wrestler_b_id = $?)", ref.ID)
+	if s := c.Query("season"); s != "" {
+		f.add("se.year = $?", s)
+	}
+	if t := c.Query("event_type"); t != "" {
+		f.add("lower(ev.event_type) = lower($?)", t)
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT se.year,
+		       COUNT(*) FILTER (WHERE b.winner_id = $1),
+		       COUNT(*) FILTER (WHERE b.winner_id <> $1)
+		`+wrestlerBoutFrom+f.clause()+`
+		GROUP BY se.year
+		ORDER BY se.year DESC
+	`, f.args...)
+	if err != nil {
+		return failed(err)
+	}
+	for rows.Next() {
+		var s SeasonRecord
+		if err := rows.Scan(&s.SeasonYear, &s.Wins, &s.Losses); err != nil {
+			rows.Close()
+			return failed(err)
+		}
+		out.Summary = append(out.Summary, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return failed(err)
+	}
+
+	out.Page, err = fetchPage(wrestlerBoutQuery(f, c.Query("source")), p, scanWrestlerBout)
+	if err != nil {
+		return failed(err)
+	}
+	for i := range out.Data {
+		out.Data[i].ResultMethod = normalizedMethod(out.Data[i].ResultRaw)
+	}
+	return c.JSON(out)
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gable-backend/internal/apikey"
+	"gable-backend/internal/pagination"
+	"gable-backend/internal/search"
+	"gable-backend/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ---------------------------------------------------------------------------
+// Response types
+// ---------------------------------------------------------------------------
+
+type SearchResult struct {
+	Type   string  `json:"type"` // wrestler, school, conference or event
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Slug   *string `json:"slug"`   // null for events
+	Detail *string `json:"detail"` // latest school, short name or event date
+	Score  float64 `json:"score"`
+}
+
+type SearchResponse struct {
+	Query string `json:"query"`
+	pagination.Page[SearchResult]
+}
+
+var searchSort = pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "score", Columns: []pagination.Column{pagination.Asc("p.score"), pagination.Desc("p.name")}},
+		{Name: "name", Columns: []pagination.Column{pagination.Asc("p.name")}},
+	},
+	Default: "-score",
+	Unique:  []string{"p.type", "p.id"},
+}
+
+// searchScopes is the key scope each result type needs.
+var searchScopes = map[string]string{
+	search.Wrestler:   apikey.ScopeWrestlers,
+	search.School:     apikey.ScopeSchools,
+	search.Conference: apikey.ScopeSchools,
+	search.Event:      apikey.ScopeResults,
+}
+
+// searchMatch builds the match condition and score for a hit. $1 is the raw
+// query, $2 its prefix tsquery. trgm columns are compared by trigram
+// similarity (typos); fts must match an index in 026_search.sql.
+func searchMatch(fts string, trgm ...string) (cond, score string) {
+	q := "core.search_norm($1)"
+	tsMatch := "to_tsvector('simple', core.search_norm(" + fts + ")) @@ to_tsquery('simple', core.search_norm($2))"
+	conds := []string{tsMatch}
+	var sims []string
+	for _, col := range trgm {
+		n := "core.search_norm(" + col + ")"
+		conds = append(conds, n+" % "+q, q+" <% "+n)
+		sims = append(sims, "similarity("+n+", "+q+")", "word_similarity("+q+", "+n+")")
+	}
+	cond = "(" + strings.Join(conds, " OR ") + ")"
+	score = "COALESCE(GREATEST(" + strings.Join(sims, ", ") + "), 0) + CASE WHEN " + tsMatch + " THEN 0.5 ELSE 0 END"
+	return cond, score
+}
+
+// searchHits returns the SELECT producing (type, id, name, slug, detail,
+// score) for one type. season is the placeholder for the season filter, or "".
+func searchHits(kind, season string) string {
+	switch kind {
+	case search.Wrestler:
+		seasonClause := ""
+		if season != "" {
+			seasonClause = ` AND EXISTS (
+				SELECT 1 FROM core.wrestler_season ws JOIN core.season se ON se.id = ws.season_id
+				WHERE ws.wrestler_id = w.id AND se.year = ` + season + `)`
+		}
+		cond, score := searchMatch("w.full_name", "w.full_name")
+		aliasCond, aliasScore := searchMatch("a.alias", "a.alias")
+		// Alias hits score slightly below the same match on the current name.
+		return `
+			SELECT 'wrestler' AS type, w.id::TEXT AS id, w.full_name AS name, w.slug AS slug,
+			       NULL::TEXT AS detail, ` + score + ` AS score
+			FROM core.wrestler w WHERE ` + cond + seasonClause + `
+			UNION ALL
+			SELECT 'wrestler', w.id::TEXT, w.full_name, w.slug, NULL::TEXT, ` + aliasScore + ` - 0.05
+			FROM core.wrestler_alias a JOIN core.wrestler w ON w.id = a.wrestler_id
+			WHERE ` + aliasCond + seasonClause
+	case search.School:
+		seasonClause := ""
+		if season != "" {
+			seasonClause = ` AND (EXISTS (
+				SELECT 1 FROM core.wrestler_season ws JOIN core.season se ON se.id = ws.season_id
+				WHERE ws.school_id = s.id AND se.year = ` + season + `
+			) OR EXISTS (
+				SELECT 1 FROM core.school_conference_season scs JOIN core.season se ON se.id = scs.season_id
+				WHERE scs.school_id = s.id AND se.year = ` + season + `
+			))`
+		}
+		cond, score := searchMatch("s.name || ' ' || COALESCE(s.short_name, '')", "s.name", "s.short_name")
+		return `
+			SELECT 'school' AS type, s.id::TEXT AS id, s.name AS name, s.slug AS slug,
+			       s.short_name AS detail, ` + score + ` AS score
+			FROM core.school s WHERE ` + cond + seasonClause
+	case search.Conference:
+		seasonClause := ""
+		if season != "" {
+			seasonClause = ` AND EXISTS (
+				SELECT 1 FROM core.school_conference_season scs JOIN core.season se ON se.id = scs.season_id
+				WHERE scs.conference_id = co.id AND se.year = ` + season + `)`
+		}
+		cond, score := searchMatch("co.name", "co.name")
+		return `
+			SELECT 'conference' AS type, co.id::TEXT AS id, co.name AS name, co.slug AS slug,
+			       NULL::TEXT AS detail, ` + score + ` AS score
+			FROM core.conference co WHERE ` + cond + seasonClause
+	case search.Event:
+		seasonClause := ""
+		if season != "" {
+			seasonClause = ` AND EXISTS (
+				SELECT 1 FROM core.season se WHERE se.id = ev.season_id AND se.year = ` + season + `)`
+		}
+		cond, score := searchMatch("ev.name", "ev.name")
+		return `
+			SELECT 'event' AS type, ev.id::TEXT AS id, ev.name AS name, NULL::TEXT AS slug,
+			       ev.event_date::TEXT AS detail, ` + score + ` AS score
+			FROM core.event ev WHERE ` + cond + seasonClause
+	}
+	return ""
+}
+
+// ---------------------------------------------------------------------------
+// GET /api/v1/search
+// Query params: q (required, at least 2 characters), type (comma-separated:
+// wrestler, school, conference, event; default all the key may read),
+// season (year int), sort (score, name; default -score), cursor, limit,
+// include_total
+// Matching ignores case and accents and tolerates typos; each word also
+// matches as a prefix. Results are ranked best first across types.
+// ---------------------------------------------------------------------------
+func V1Search(c *fiber.Ctx) error {
+	q := strings.TrimSpace(c.Query("q"))
+	tsQuery := search.PrefixQuery(q)
+	if utf8.RuneCountInString(q) < search.MinQueryLen || tsQuery == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "q must be at least 2 characters"})
+	}
+	p, done, err := pageParams(c, searchSort)
+	if done {
+		return err
+	}
+
+	kinds, err := search.ParseTypes(c.Query("type"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "type must be wrestler, school, conference or event"})
+	}
+	explicit := c.Query("type") != ""
+	client, _ := c.Locals("api_client").(middleware.APIClient)
+	var allowed []string
+	for _, k := range kinds {
+		scope := searchScopes[k]
+		if client.HasScope(scope) {
+			allowed = append(allowed, k)
+		} else if explicit {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API key is missing scope: " + scope})
+		}
+	}
+	if len(allowed) == 0 {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API key has no searchable scopes"})
+	}
+
+	// searchHits expects the query in $1 and its tsquery in $2.
+	var f queryFilter
+	f.bind(q)
+	f.bind(tsQuery)
+	season := ""
+	if s := c.Query("season"); s != "" {
+		year, err := strconv.Atoi(s)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "season must be a year"})
+		}
+		season = f.bind(year)
+	}
+
+	var parts []string
+	for _, k := range allowed {
+		parts = append(parts, searchHits(k, season))
+	}
+
+	// A wrestler matched by name and by alias appears once, at its best score.
+	page, err := fetchPage(pageQuery{
+		With: `
+			WITH hits AS (` + strings.Join(parts, "\nUNION ALL\n") + `),
+			best AS (
+				SELECT DISTINCT ON (type, id) type, id, name, slug, detail,
+				       round(score::NUMERIC, 3)::FLOAT8 AS score
+				FROM hits ORDER BY type, id, score DESC
+			)`,
+		Columns: `
+			p.type, p.id, p.name, p.slug,
+			COALESCE(p.detail, (
+				SELECT sc.name
+				FROM core.wrestler_season ws
+				JOIN core.season se ON se.id = ws.season_id
+				JOIN core.school sc ON sc.id = ws.school_id
+				WHERE p.type = 'wrestler' AND ws.wrestler_id = p.id::UUID
+				ORDER BY se.year DESC
+				LIMIT 1
+			)),
+			p.score`,
+		From:   `FROM best p`,
+		Filter: f,
+	}, p, func(r *SearchResult) []interface{} {
+		return []interface{}{&r.Type, &r.ID, &r.Name, &r.Slug, &r.Detail, &r.Score}
+	})
+	if err != nil {
+		log.Printf("platform_search error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+	return c.JSON(SearchResponse{Query: q, Page: page})
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"gable-backend/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ---------------------------------------------------------------------------
+// Response types
+// ---------------------------------------------------------------------------
+
+type WrestlerSeasonStats struct {
+	SeasonYear  int             `json:"season_year"`
+	SeasonLabel string          `json:"season_label"`
+	ComputedAt  time.Time       `json:"computed_at"`
+	Stats       json.RawMessage `json:"stats"` // stats.Line
+}
+
+type WrestlerStatsResponse struct {
+	Wrestler WrestlerRef           `json:"wrestler"`
+	Seasons  []WrestlerSeasonStats `json:"seasons"`
+}
+
+// ---------------------------------------------------------------------------
+// GET /api/v1/stats/wrestler/:slug
+// :slug is the wrestler's slug or UUID; a retired slug redirects (301).
+// Query params: season (year int)
+// Returns stat lines derived from bouts, newest season first. Lines are
+// recomputed after each results import.
+// ---------------------------------------------------------------------------
+func V1GetWrestlerStats(c *fiber.Ctx) error {
+	ref, done, err := resolvePathRef(c, wrestlerEntity, "slug")
+	if done {
+		return err
+	}
+
+	out := WrestlerStatsResponse{Seasons: []WrestlerSeasonStats{}}
+	err = database.DB.QueryRow(
+		`SELECT id, full_name, slug FROM core.wrestler WHERE id = $1`, ref.ID,
+	).Scan(&out.Wrestler.ID, &out.Wrestler.Name, &out.Wrestler.Slug)
+	if err != nil {
+		log.Printf("platform_stats error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	args := []interface{}{ref.ID}
+	seasonClause := ""
+	if s := c.Query("season"); s != "" {
+		args = append(args, s)
+		seasonClause = "AND se.year = $2"
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT se.year, se.label, st.computed_at, st.stats
+		FROM core.wrestler_season_stats st
+		JOIN core.season se ON se.id = st.season_id
+		WHERE st.wrestler_id = $1 `+seasonClause+`
+		ORDER BY se.year DESC
+	`, args...)
+	if err != nil {
+		log.Printf("platform_stats error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s WrestlerSeasonStats
+		var raw []byte
+		if err := rows.Scan(&s.SeasonYear, &s.SeasonLabel, &s.ComputedAt, &raw); err != nil {
+			log.Printf("platform_stats error: %v", err)
+			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		}
+		s.Stats = raw
+		out.Seasons = append(out.Seasons, s)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("platform_stats error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	return c.JSON(out)
+}
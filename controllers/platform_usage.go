@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/internal/ratelimit"
+	"gable-backend/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UsageRow struct {
+	Day    string `json:"day"`
+	Route  string `json:"route"`
+	Status int    `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+type UsageWindow struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"` // 0 = unlimited
+}
+
+// usageDays parses ?days= (default 7, max 90).
+func usageDays(c *fiber.Ctx) int {
+	days, _ := strconv.Atoi(c.Query("days", "7"))
+	if days < 1 || days > 90 {
+		days = 7
+	}
+	return days
+}
+
+// ---------------------------------------------------------------------------
+// GET /api/v1/me/usage
+// Query params: days (default 7, max 90)
+// Requires an API key. Returns the key's tier, current quota windows and
+// request counts per day, route and status. Counts lag by up to ~10 seconds.
+// ---------------------------------------------------------------------------
+func V1GetMyUsage(c *fiber.Ctx) error {
+	client, _ := c.Locals("api_client").(middleware.APIClient)
+	if client.KeyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "X-API-Key header is required"})
+	}
+	days := usageDays(c)
+
+	var perMinute, perDay int
+	err := database.DB.QueryRow(`SELECT per_minute, per_day FROM api_tiers WHERE name = $1`, client.Tier).
+		Scan(&perMinute, &perDay)
+	if err != nil {
+		log.Printf("platform_usage error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	subject := "key:" + client.KeyID
+	minute, day := ratelimit.Windows(time.Now())
+	var usedMinute, usedDay int
+	err = database.DB.QueryRow(`
+		SELECT
+			COALESCE(MAX(count) FILTER (WHERE window_kind = 'minute' AND window_start = $2), 0),
+			COALESCE(MAX(count) FILTER (WHERE window_kind = 'day'    AND window_start = $3), 0)
+		FROM api_rate_counters
+		WHERE subject = $1
+	`, subject, minute, day).Scan(&usedMinute, &usedDay)
+	if err != nil {
+		log.Printf("platform_usage error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT day::TEXT, route, status, count
+		FROM api_usage_daily
+		WHERE subject = $1 AND day > CURRENT_DATE - $2::INT
+		ORDER BY day DESC, route, status
+	`, subject, days)
+	if err != nil {
+		log.Printf("platform_usage error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+	defer rows.Close()
+
+	usage := make([]UsageRow, 0)
+	for rows.Next() {
+		var u UsageRow
+		if err := rows.Scan(&u.Day, &u.Route, &u.Status, &u.Count); err != nil {
+			log.Printf("platform_usage scan error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+		}
+		usage = append(usage, u)
+	}
+
+	return c.JSON(fiber.Map{
+		"key_id": client.KeyID,
+		"tier":   client.Tier,
+		"minute": UsageWindow{Used: usedMinute, Limit: perMinute},
+		"day":    UsageWindow{Used: usedDay, Limit: perDay},
+		"usage":  usage,
+	})
+}
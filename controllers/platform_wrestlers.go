@@ -2,11 +2,12 @@ package controllers
 
 import (
 	"database/sql"
+	"errors"
 	"log"
-	"strconv"
-	"strings"
 
 	"gable-backend/database"
+	"gable-backend/internal/cache"
+	"gable-backend/internal/pagination"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -46,13 +47,6 @@ type WrestlerProfile struct {
 	Seasons       []WrestlerSeasonSummary `json:"seasons"`
 }
 
-type PaginatedWrestlers struct {
-	Data       []WrestlerListItem `json:"data"`
-	Page       int                `json:"page"`
-	PerPage    int                `json:"per_page"`
-	Total      int                `json:"total"`
-}
-
 type SchoolListItem struct {
 	ID        string `json:"id"`
 	Name      string `json:"name"`
@@ -91,75 +85,61 @@ type SeasonListItem struct {
 	EndDate   *string `json:"end_date"`
 }
 
+var wrestlerSort = pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "weight", Columns: []pagination.Column{pagination.Asc("wc.sort_order"), pagination.Asc("w.full_name")}},
+		{Name: "name", Columns: []pagination.Column{pagination.Asc("w.full_name")}},
+		{Name: "season", Columns: []pagination.Column{pagination.Asc("se.year"), pagination.Asc("wc.sort_order"), pagination.Asc("w.full_name")}},
+	},
+	Default: "weight",
+	Unique:  []string{"w.id", "se.id"},
+}
+
 // ---------------------------------------------------------------------------
 // GET /api/v1/wrestlers
-// Query params: season (year int), weight_class, school (slug), conference (slug),
-//               page (default 1), per_page (default 50, max 200)
+// Query params: season (year int), weight_class, school (UUID or slug),
+// conference (UUID or slug), sort (weight, name, season; default weight),
+// cursor, limit, include_total
 // ---------------------------------------------------------------------------
 func V1GetWrestlers(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	perPage, _ := strconv.Atoi(c.Query("per_page", "50"))
-	if page < 1 {
-		page = 1
+	p, done, err := pageParams(c, wrestlerSort)
+	if done {
+		return err
 	}
-	if perPage < 1 || perPage > 200 {
-		perPage = 50
-	}
-
-	args := []interface{}{}
-	where := []string{}
-	i := 1
 
+	var f queryFilter
 	if s := c.Query("season"); s != "" {
-		args = append(args, s)
-		where = append(where, "se.year = $"+strconv.Itoa(i))
-		i++
+		f.add("se.year = $?", s)
 	}
 	if wc := c.Query("weight_class"); wc != "" {
-		args = append(args, wc)
-		where = append(where, "wc.label = $"+strconv.Itoa(i))
-		i++
-	}
-	if school := c.Query("school"); school != "" {
-		args = append(args, school)
-		where = append(where, "sc.slug = $"+strconv.Itoa(i))
-		i++
-	}
-	if conf := c.Query("conference"); conf != "" {
-		args = append(args, conf)
-		where = append(where, "co.slug = $"+strconv.Itoa(i))
-		i++
+		f.add("wc.label = $?", wc)
 	}
-
-	whereClause := ""
-	if len(where) > 0 {
-		whereClause = "WHERE " + strings.Join(where, " AND ")
-	}
-
-	// Total count
-	countQuery := `
-		SELECT COUNT(*)
-		FROM core.wrestler w
-		JOIN core.wrestler_season ws ON ws.wrestler_id = w.id
-		JOIN core.season se ON se.id = ws.season_id
-		JOIN core.school sc ON sc.id = ws.school_id
-		JOIN core.weight_class wc ON wc.id = ws.primary_weight_class_id
-		LEFT JOIN core.school_conference_season scs ON scs.school_id = sc.id AND scs.season_id = se.id
-		LEFT JOIN core.conference co ON co.id = scs.conference_id
-		` + whereClause
-
-	var total int
-	if err := database.DB.QueryRow(countQuery, args...).Scan(&total); err != nil {
-		log.Printf("platform_wrestlers error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	// school and conference take a UUID or slug; retired slugs still match.
+	for _, param := range []struct {
+		name   string
+		kind   entityKind
+		column string
+	}{
+		{"school", schoolEntity, "sc.id"},
+		{"conference", conferenceEntity, "co.id"},
+	} {
+		ref := c.Query(param.name)
+		if ref == "" {
+			continue
+		}
+		r, err := resolveRef(param.kind, ref)
+		if errors.Is(err, errRefNotFound) {
+			return c.JSON(pagination.Page[WrestlerListItem]{Data: []WrestlerListItem{}})
+		}
+		if err != nil {
+			log.Printf("platform_wrestlers error: %v", err)
+			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		}
+		f.add(param.column+" = $?", r.ID)
 	}
 
-	// Paginated results
-	offset := (page - 1) * perPage
-	args = append(args, perPage, offset)
-
-	dataQuery := `
-		SELECT
+	out, err := fetchPage(pageQuery{
+		Columns: `
 			w.id, w.full_name, w.slug, w.wrestlestat_id::TEXT,
 			se.year, se.label,
 			sc.name, sc.slug,
@@ -167,73 +147,51 @@ func V1GetWrestlers(c *fiber.Ctx) error {
 			wc.label,
 			COALESCE(ws.class_year, ''),
 			ws.wins, ws.losses,
-			ws.win_percentage::TEXT, ws.ncaa_finish
-		FROM core.wrestler w
-		JOIN core.wrestler_season ws ON ws.wrestler_id = w.id
-		JOIN core.season se ON se.id = ws.season_id
-		JOIN core.school sc ON sc.id = ws.school_id
-		JOIN core.weight_class wc ON wc.id = ws.primary_weight_class_id
-		LEFT JOIN core.school_conference_season scs ON scs.school_id = sc.id AND scs.season_id = se.id
-		LEFT JOIN core.conference co ON co.id = scs.conference_id
-		` + whereClause + `
-		ORDER BY wc.sort_order, w.full_name
-		LIMIT $` + strconv.Itoa(i) + ` OFFSET $` + strconv.Itoa(i+1)
-
-	rows, err := database.DB.Query(dataQuery, args...)
-	if err != nil {
-		log.Printf("platform_wrestlers error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
-	}
-	defer rows.Close()
-
-	wrestlers := []WrestlerListItem{}
-	for rows.Next() {
-		var item WrestlerListItem
-		var s WrestlerSeasonSummary
-		var winPct sql.NullString
-		var ncaaFinish sql.NullString
-		if err := rows.Scan(
+			ws.win_percentage::TEXT, ws.ncaa_finish`,
+		From: `
+			FROM core.wrestler w
+			JOIN core.wrestler_season ws ON ws.wrestler_id = w.id
+			JOIN core.season se ON se.id = ws.season_id
+			JOIN core.school sc ON sc.id = ws.school_id
+			JOIN core.weight_class wc ON wc.id = ws.primary_weight_class_id
+			LEFT JOIN core.school_conference_season scs ON scs.school_id = sc.id AND scs.season_id = se.id
+			LEFT JOIN core.conference co ON co.id = scs.conference_id`,
+		Filter: f,
+	}, p, func(item *WrestlerListItem) []interface{} {
+		s := &item.Season
+		return []interface{}{
 			&item.ID, &item.FullName, &item.Slug, &item.WrestlestatID,
 			&s.SeasonYear, &s.SeasonLabel,
 			&s.School, &s.SchoolSlug,
 			&s.Conference, &s.ConferenceSlug,
 			&s.WeightClass, &s.ClassYear,
 			&s.RecordWins, &s.RecordLosses,
-			&winPct, &ncaaFinish,
-		); err != nil {
-			log.Printf("platform_wrestlers error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
-		}
-		if winPct.Valid {
-			s.WinPct = &winPct.String
-		}
-		if ncaaFinish.Valid {
-			s.NcaaFinish = &ncaaFinish.String
+			&s.WinPct, &s.NcaaFinish,
 		}
-		item.Season = s
-		wrestlers = append(wrestlers, item)
-	}
-
-	return c.JSON(PaginatedWrestlers{
-		Data:    wrestlers,
-		Page:    page,
-		PerPage: perPage,
-		Total:   total,
 	})
+	if err != nil {
+		log.Printf("platform_wrestlers error: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+	return c.JSON(out)
 }
 
 // ---------------------------------------------------------------------------
 // GET /api/v1/wrestlers/:id
+// :id is the wrestler's UUID or slug; a retired slug redirects (301).
 // ---------------------------------------------------------------------------
 func V1GetWrestler(c *fiber.Ctx) error {
-	id := c.Params("id")
+	ref, done, err := resolvePathRef(c, wrestlerEntity, "id")
+	if done {
+		return err
+	}
 
 	// Basic identity
 	var profile WrestlerProfile
-	err := database.DB.QueryRow(`
+	err = database.DB.QueryRow(`
 		SELECT id, full_name, slug, wrestlestat_id
 		FROM core.wrestler WHERE id = $1
-	`, id).Scan(&profile.ID, &profile.FullName, &profile.Slug, &profile.WrestlestatID)
+	`, ref.ID).Scan(&profile.ID, &profile.FullName, &profile.Slug, &profile.WrestlestatID)
 	if err == sql.ErrNoRows {
 		return c.Status(404).JSON(fiber.Map{"error": "wrestler not found"})
 	}
@@ -295,45 +253,51 @@ func V1GetWrestler(c *fiber.Ctx) error {
 	return c.JSON(profile)
 }
 
+var schoolSort = pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "name", Columns: []pagination.Column{pagination.Asc("name")}},
+		{Name: "slug", Columns: []pagination.Column{pagination.Asc("slug")}},
+	},
+	Default: "name",
+	Unique:  []string{"id"},
+}
+
 // ---------------------------------------------------------------------------
 // GET /api/v1/schools
+// Query params: sort (name, slug; default name), cursor, limit, include_total
 // ---------------------------------------------------------------------------
 func V1GetSchools(c *fiber.Ctx) error {
-	rows, err := database.DB.Query(`
-		SELECT id, name, slug, COALESCE(short_name, '')
-		FROM core.school
-		ORDER BY name
-	`)
+	p, done, err := pageParams(c, schoolSort)
+	if done {
+		return err
+	}
+	out, err := fetchPage(pageQuery{
+		Columns: `id, name, slug, COALESCE(short_name, '')`,
+		From:    `FROM core.school`,
+	}, p, func(s *SchoolListItem) []interface{} {
+		return []interface{}{&s.ID, &s.Name, &s.Slug, &s.ShortName}
+	})
 	if err != nil {
 		log.Printf("platform_wrestlers error: %v", err)
 		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
 	}
-	defer rows.Close()
-
-	schools := []SchoolListItem{}
-	for rows.Next() {
-		var s SchoolListItem
-		if err := rows.Scan(&s.ID, &s.Name, &s.Slug, &s.ShortName); err != nil {
-			log.Printf("platform_wrestlers error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
-		}
-		schools = append(schools, s)
-	}
-	return c.JSON(schools)
+	return c.JSON(out)
 }
 
 // ---------------------------------------------------------------------------
 // GET /api/v1/schools/:slug
+// :slug is the school's slug or UUID; a retired slug redirects (301).
 // Query params: season (year int) — filters roster to that season
 // ---------------------------------------------------------------------------
 func V1GetSchool(c *fiber.Ctx) error {
-	slug := c.Params("slug")
+	ref, done, err := resolvePathRef(c, schoolEntity, "slug")
+	if done {
+		return err
+	}
 
-	var school SchoolProfile
-	err := database.DB.QueryRow(`
-		SELECT id, name, slug, COALESCE(short_name, '')
-		FROM core.school WHERE slug = $1
-	`, slug).Scan(&school.ID, &school.Name, &school.Slug, &school.ShortName)
+	season := c.Query("season")
+	school, err := cache.Fetch(cache.Default, "v1:school:"+ref.ID+":"+season, []string{cache.TopicCore},
+		func() (SchoolProfile, error) { return loadSchoolProfile(ref.ID, season) })
 	if err == sql.ErrNoRows {
 		return c.Status(404).JSON(fiber.Map{"error": "school not found"})
 	}
@@ -341,6 +305,20 @@ func V1GetSchool(c *fiber.Ctx) error {
 		log.Printf("platform_wrestlers error: %v", err)
 		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
 	}
+	return c.JSON(school)
+}
+
+// loadSchoolProfile reads a school with its conference history and roster,
+// limited to one season year when season is set.
+func loadSchoolProfile(id, season string) (SchoolProfile, error) {
+	var school SchoolProfile
+	err := database.DB.QueryRow(`
+		SELECT id, name, slug, COALESCE(short_name, '')
+		FROM core.school WHERE id = $1
+	`, id).Scan(&school.ID, &school.Name, &school.Slug, &school.ShortName)
+	if err != nil {
+		return school, err
+	}
 
 	// Conference history
 	confRows, err := database.DB.Query(`
@@ -352,8 +330,7 @@ func V1GetSchool(c *fiber.Ctx) error {
 		ORDER BY se.year DESC
 	`, school.ID)
 	if err != nil {
-		log.Printf("platform_wrestlers error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		return school, err
 	}
 	defer confRows.Close()
 
@@ -361,8 +338,7 @@ func V1GetSchool(c *fiber.Ctx) error {
 	for confRows.Next() {
 		var e ConferenceSeasonEntry
 		if err := confRows.Scan(&e.ConferenceID, &e.ConferenceName, &e.ConferenceSlug, &e.SeasonYear, &e.SeasonLabel); err != nil {
-			log.Printf("platform_wrestlers error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+			return school, err
 		}
 		school.Conferences = append(school.Conferences, e)
 	}
@@ -370,8 +346,8 @@ func V1GetSchool(c *fiber.Ctx) error {
 	// Roster — optional season filter
 	rosterArgs := []interface{}{school.ID}
 	seasonFilter := ""
-	if s := c.Query("season"); s != "" {
-		rosterArgs = append(rosterArgs, s)
+	if season != "" {
+		rosterArgs = append(rosterArgs, season)
 		seasonFilter = "AND se.year = $2"
 	}
 
@@ -396,8 +372,7 @@ func V1GetSchool(c *fiber.Ctx) error {
 		ORDER BY wc.sort_order, w.full_name
 	`, rosterArgs...)
 	if err != nil {
-		log.Printf("platform_wrestlers error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		return school, err
 	}
 	defer rosterRows.Close()
 
@@ -416,8 +391,7 @@ func V1GetSchool(c *fiber.Ctx) error {
 			&s.RecordWins, &s.RecordLosses,
 			&winPct, &ncaaFinish,
 		); err != nil {
-			log.Printf("platform_wrestlers error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+			return school, err
 		}
 		if winPct.Valid {
 			s.WinPct = &winPct.String
@@ -429,63 +403,65 @@ func V1GetSchool(c *fiber.Ctx) error {
 		school.Roster = append(school.Roster, item)
 	}
 
-	return c.JSON(school)
+	return school, rosterRows.Err()
+}
+
+var conferenceSort = pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "name", Columns: []pagination.Column{pagination.Asc("name")}},
+	},
+	Default: "name",
+	Unique:  []string{"id"},
 }
 
 // ---------------------------------------------------------------------------
 // GET /api/v1/conferences
+// Query params: sort (name; default name), cursor, limit, include_total
 // ---------------------------------------------------------------------------
 func V1GetConferences(c *fiber.Ctx) error {
-	rows, err := database.DB.Query(`
-		SELECT id, name, slug FROM core.conference ORDER BY name
-	`)
+	p, done, err := pageParams(c, conferenceSort)
+	if done {
+		return err
+	}
+	out, err := fetchPage(pageQuery{
+		Columns: `id, name, slug`,
+		From:    `FROM core.conference`,
+	}, p, func(conf *ConferenceListItem) []interface{} {
+		return []interface{}{&conf.ID, &conf.Name, &conf.Slug}
+	})
 	if err != nil {
 		log.Printf("platform_wrestlers error: %v", err)
 		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
 	}
-	defer rows.Close()
+	return c.JSON(out)
+}
 
-	conferences := []ConferenceListItem{}
-	for rows.Next() {
-		var conf ConferenceListItem
-		if err := rows.Scan(&conf.ID, &conf.Name, &conf.Slug); err != nil {
-			log.Printf("platform_wrestlers error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
-		}
-		conferences = append(conferences, conf)
-	}
-	return c.JSON(conferences)
+var seasonSort = pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "year", Columns: []pagination.Column{pagination.Asc("year")}},
+	},
+	Default: "-year",
+	Unique:  []string{"id"},
 }
 
 // ---------------------------------------------------------------------------
 // GET /api/v1/seasons
+// Query params: sort (year; default -year), cursor, limit, include_total
 // ---------------------------------------------------------------------------
 func V1GetSeasons(c *fiber.Ctx) error {
-	rows, err := database.DB.Query(`
-		SELECT id, year, label, start_date::TEXT, end_date::TEXT
-		FROM core.season ORDER BY year DESC
-	`)
+	p, done, err := pageParams(c, seasonSort)
+	if done {
+		return err
+	}
+	out, err := fetchPage(pageQuery{
+		Columns: `id, year, label, start_date::TEXT, end_date::TEXT`,
+		From:    `FROM core.season`,
+	}, p, func(s *SeasonListItem) []interface{} {
+		return []interface{}{&s.ID, &s.Year, &s.Label, &s.StartDate, &s.EndDate}
+	})
 	if err != nil {
 		log.Printf("platform_wrestlers error: %v", err)
 		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
 	}
-	defer rows.Close()
-
-	seasons := []SeasonListItem{}
-	for rows.Next() {
-		var s SeasonListItem
-		var start, end sql.NullString
-		if err := rows.Scan(&s.ID, &s.Year, &s.Label, &start, &end); err != nil {
-			log.Printf("platform_wrestlers error: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
-		}
-		if start.Valid {
-			s.StartDate = &start.String
-		}
-		if end.Valid {
-			s.EndDate = &end.String
-		}
-		seasons = append(seasons, s)
-	}
-	return c.JSON(seasons)
+	return c.JSON(out)
 }
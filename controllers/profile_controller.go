@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"gable-backend/database"
+	"gable-backend/internal/profile"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+type SchoolRef struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type ProfileSettings struct {
+	DisplayName    *string    `json:"display_name"`
+	AvatarSeed     string     `json:"avatar_seed"`
+	FavoriteSchool *SchoolRef `json:"favorite_school"`
+	ProfilePublic  bool       `json:"profile_public"`
+	ShowStats      bool       `json:"show_stats"`
+}
+
+type PublicProfileStats struct {
+	TotalWins       int             `json:"total_wins"`
+	TotalLosses     int             `json:"total_losses"`
+	CurrentStreak   int             `json:"current_streak"`
+	MaxStreak       int             `json:"max_streak"`
+	WinDistribution json.RawMessage `json:"win_distribution"`
+}
+
+type PublicProfile struct {
+	DisplayName    string              `json:"display_name"`
+	AvatarSeed     string              `json:"avatar_seed"`
+	FavoriteSchool *SchoolRef          `json:"favorite_school"`
+	Stats          *PublicProfileStats `json:"stats"` // null when the user hides stats
+}
+
+// loadProfileSettings reads the caller-editable profile fields for userID.
+func loadProfileSettings(userID int) (ProfileSettings, error) {
+	var p ProfileSettings
+	var displayName, schoolName, schoolSlug sql.NullString
+	err := database.DB.QueryRow(`
+		SELECT u.display_name, COALESCE(u.avatar_seed, ''), u.profile_public, u.show_stats,
+		       s.name, s.slug
+		FROM users u
+		LEFT JOIN core.school s ON s.id = u.favorite_school_id
+		WHERE u.id = $1
+	`, userID).Scan(&displayName, &p.AvatarSeed, &p.ProfilePublic, &p.ShowStats, &schoolName, &schoolSlug)
+	if err != nil {
+		return p, err
+	}
+	if displayName.Valid {
+		p.DisplayName = &displayName.String
+	}
+	if schoolSlug.Valid {
+		p.FavoriteSchool = &SchoolRef{Name: schoolName.String, Slug: schoolSlug.String}
+	}
+	return p, nil
+}
+
+// GET /api/gable/user/profile
+func GetProfile(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	p, err := loadProfileSettings(userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+	return c.JSON(p)
+}
+
+// PUT /api/gable/user/profile
+// Partial update: omitted fields are left unchanged. An empty favorite_school
+// or avatar_seed clears it. Display names cannot be cleared, only changed.
+func UpdateProfile(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req struct {
+		DisplayName    *string `json:"display_name"`
+		AvatarSeed     *string `json:"avatar_seed"`
+		FavoriteSchool *string `json:"favorite_school"` // school slug
+		ProfilePublic  *bool   `json:"profile_public"`
+		ShowStats      *bool   `json:"show_stats"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	sets := []string{}
+	args := []any{}
+	add := func(expr string, v any) {
+		args = append(args, v)
+		sets = append(sets, strings.Replace(expr, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+
+	if req.DisplayName != nil {
+		name := profile.NormalizeDisplayName(*req.DisplayName)
+		if err := profile.ValidateDisplayName(name); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		add("display_name = ?", name)
+		sets = append(sets, "display_name_changed_at = CASE WHEN display_name IS DISTINCT FROM "+
+			"$"+strconv.Itoa(len(args))+" THEN now() ELSE display_name_changed_at END")
+	}
+	if req.AvatarSeed != nil {
+		seed := strings.TrimSpace(*req.AvatarSeed)
+		if err := profile.ValidateAvatarSeed(seed); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		add("avatar_seed = NULLIF(?, '')", seed)
+	}
+	if req.FavoriteSchool != nil {
+		slug := strings.TrimSpace(*req.FavoriteSchool)
+		if slug == "" {
+			sets = append(sets, "favorite_school_id = NULL")
+		} else {
+			var schoolID string
+			err := database.DB.QueryRow(`SELECT id FROM core.school WHERE slug = $1`, slug).Scan(&schoolID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown school"})
+			}
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to look up school"})
+			}
+			add("favorite_school_id = ?", schoolID)
+		}
+	}
+	if req.ProfilePublic != nil {
+		add("profile_public = ?", *req.ProfilePublic)
+	}
+	if req.ShowStats != nil {
+		add("show_stats = ?", *req.ShowStats)
+	}
+
+	if len(sets) > 0 {
+		args = append(args, userID)
+		_, err := database.DB.Exec(
+			"UPDATE users SET "+strings.Join(sets, ", ")+" WHERE id = $"+strconv.Itoa(len(args)),
+			args...,
+		)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Display name is taken"})
+			}
+			log.Printf("update profile: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update profile"})
+		}
+	}
+
+	p, err := loadProfileSettings(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load profile"})
+	}
+	return c.JSON(p)
+}
+
+// GET /api/gable/users/:name
+// Public profile by display name (case-insensitive). Private profiles are
+// reported as not found so their existence is not revealed.
+func GetPublicProfile(c *fiber.Ctx) error {
+	name := profile.NormalizeDisplayName(c.Params("name"))
+	if name == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Profile not found"})
+	}
+
+	var p PublicProfile
+	var showStats bool
+	var schoolName, schoolSlug sql.NullString
+	var stats PublicProfileStats
+	var totalWins, totalLosses, currentStreak, maxStreak sql.NullInt64
+	var dist []byte
+	err := database.DB.QueryRow(`
+		SELECT u.display_name, COALESCE(u.avatar_seed, ''), u.show_stats,
+		       s.name, s.slug,
+		       st.total_wins, st.total_losses, st.current_streak, st.max_streak, st.win_distribution
+		FROM users u
+		LEFT JOIN core.school s ON s.id = u.favorite_school_id
+		LEFT JOIN user_stats st ON st.user_id = u.id
+		WHERE lower(u.display_name) = lower($1) AND u.profile_public
+	`, name).Scan(&p.DisplayName, &p.AvatarSeed, &showStats, &schoolName, &schoolSlug,
+		&totalWins, &totalLosses, &currentStreak, &maxStreak, &dist)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Profile not found"})
+	}
+	if err != nil {
+		log.Printf("public profile: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load profile"})
+	}
+
+	if schoolSlug.Valid {
+		p.FavoriteSchool = &SchoolRef{Name: schoolName.String, Slug: schoolSlug.String}
+	}
+	if showStats {
+		stats.TotalWins = int(totalWins.Int64)
+		stats.TotalLosses = int(totalLosses.Int64)
+		stats.CurrentStreak = int(currentStreak.Int64)
+		stats.MaxStreak = int(maxStreak.Int64)
+		stats.WinDistribution = json.RawMessage("{}")
+		if len(dist) > 0 {
+			stats.WinDistribution = dist
+		}
+		p.Stats = &stats
+	}
+
+	return c.JSON(p)
+}
+
+// DELETE /api/admin/users/:id/display-name
+// Moderation: clears an offensive display name; the user must pick a new one.
+func ResetDisplayName(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || userID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user id"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start transaction"})
+	}
+	defer tx.Rollback()
+
+	var previous sql.NullString
+	err = tx.QueryRow(`SELECT display_name FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to reset display name"})
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE users SET display_name = NULL, display_name_changed_at = now()
+		WHERE id = $1
+	`, userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to reset display name"})
+	}
+	if err := recordAudit(c, tx, auditEntry{
+		Action:     "users.display_name.reset",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+		Before:     fiber.Map{"displayName": previous.String},
+		After:      fiber.Map{"displayName": nil},
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to reset display name"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to reset display name"})
+	}
+	return c.JSON(fiber.Map{"userId": userID, "displayName": nil})
+}
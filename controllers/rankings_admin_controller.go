@@ -79,8 +79,14 @@ func CreateRankingsRelease(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "weekOf must be YYYY-MM-DD"})
 	}
 
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start transaction"})
+	}
+	defer tx.Rollback()
+
 	var releaseID int
-	err := database.DB.QueryRow(`
+	err = tx.QueryRow(`
 		INSERT INTO rankings_releases (source, season, week_of)
 		VALUES ($1, $2, $3::date)
 		RETURNING id
@@ -95,6 +101,18 @@ func CreateRankingsRelease(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create release"})
 	}
 
+	if err := recordAudit(c, tx, auditEntry{
+		Action:     "rankings.release.create",
+		TargetType: "rankings_release",
+		TargetID:   strconv.Itoa(releaseID),
+		After:      req,
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create release"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create release"})
+	}
+
 	return GetRankingsReleaseDetailByID(c, releaseID)
 }
 
@@ -265,6 +283,15 @@ func ImportRankingsStaging(c *fiber.Ctx) error {
 		}
 	}
 
+	if err := recordAudit(c, tx, auditEntry{
+		Action:     "rankings.staging.import",
+		TargetType: "rankings_release",
+		TargetID:   strconv.Itoa(releaseID),
+		After:      fiber.Map{"weightClass": req.WeightClass, "rows": parsed},
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to commit staging import"})
+	}
+
 	if err := tx.Commit(); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to commit staging import"})
 	}
@@ -296,13 +323,46 @@ func ClearRankingsStagingForWeight(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Cannot clear staging rows for a published release"})
 	}
 
-	_, err = database.DB.Exec(`
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start transaction"})
+	}
+	defer tx.Rollback()
+
+	// Deleted rows are kept in the audit entry so a mistaken clear can be re-entered.
+	rows, err := tx.Query(`
 		DELETE FROM rankings_release_staging_rows
 		WHERE release_id = $1 AND weight_class = $2
+		RETURNING id, rank, name, school, previous_rank, wrestlestat_id, row_status
 	`, releaseID, weightClass)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to clear staging rows"})
 	}
+	deleted := make([]StagingRow, 0)
+	for rows.Next() {
+		r := StagingRow{ReleaseID: releaseID, WeightClass: weightClass}
+		if err := rows.Scan(&r.ID, &r.Rank, &r.Name, &r.School, &r.PreviousRank, &r.WrestlestatID, &r.RowStatus); err != nil {
+			rows.Close()
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to clear staging rows"})
+		}
+		deleted = append(deleted, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to clear staging rows"})
+	}
+
+	if err := recordAudit(c, tx, auditEntry{
+		Action:     "rankings.staging.clear",
+		TargetType: "rankings_release",
+		TargetID:   strconv.Itoa(releaseID),
+		Before:     fiber.Map{"weightClass": weightClass, "rows": deleted},
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to clear staging rows"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to clear staging rows"})
+	}
 
 	return c.JSON(fiber.Map{"ok": true})
 }
@@ -331,6 +391,36 @@ func AttachWrestleStatIDs(c *fiber.Ctx) error {
 	}
 	defer tx.Rollback()
 
+	rowIDs := make([]int64, 0, len(req.Items))
+	for _, it := range req.Items {
+		rowIDs = append(rowIDs, int64(it.RowID))
+	}
+	type attachSnapshot struct {
+		ReleaseID     int    `json:"releaseId"`
+		WrestlestatID *int   `json:"wrestlestatId"`
+		RowStatus     string `json:"status"`
+	}
+	before := map[int]attachSnapshot{}
+	snapRows, err := tx.Query(`
+		SELECT id, release_id, wrestlestat_id, row_status
+		FROM rankings_release_staging_rows
+		WHERE id = ANY($1)
+		FOR UPDATE
+	`, pq.Array(rowIDs))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load staging rows"})
+	}
+	for snapRows.Next() {
+		var id int
+		var snap attachSnapshot
+		if err := snapRows.Scan(&id, &snap.ReleaseID, &snap.WrestlestatID, &snap.RowStatus); err != nil {
+			snapRows.Close()
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load staging rows"})
+		}
+		before[id] = snap
+	}
+	snapRows.Close()
+
 	// NOTE: if your column is row_status instead of status, change it here.
 	stmt, err := tx.Prepare(`
 		UPDATE rankings_release_staging_rows
@@ -365,6 +455,37 @@ func AttachWrestleStatIDs(c *fiber.Ctx) error {
 		results = append(results, itemResult{RowID: it.RowID, OK: true})
 	}
 
+	// One entry per release touched, holding only the rows that changed.
+	type attachChange struct {
+		Before map[int]attachSnapshot
+		After  map[int]int
+	}
+	changes := map[int]*attachChange{}
+	for i, r := range results {
+		if !r.OK {
+			continue
+		}
+		snap := before[r.RowID]
+		ch := changes[snap.ReleaseID]
+		if ch == nil {
+			ch = &attachChange{Before: map[int]attachSnapshot{}, After: map[int]int{}}
+			changes[snap.ReleaseID] = ch
+		}
+		ch.Before[r.RowID] = snap
+		ch.After[r.RowID] = req.Items[i].WrestleStatID
+	}
+	for releaseID, ch := range changes {
+		if err := recordAudit(c, tx, auditEntry{
+			Action:     "rankings.staging.attach",
+			TargetType: "rankings_release",
+			TargetID:   strconv.Itoa(releaseID),
+			Before:     ch.Before,
+			After:      ch.After,
+		}); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to commit attach"})
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to commit attach"})
 	}
@@ -449,6 +570,16 @@ func PublishRankingsRelease(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update release status"})
 	}
 
+	if err := recordAudit(c, tx, auditEntry{
+		Action:     "rankings.release.publish",
+		TargetType: "rankings_release",
+		TargetID:   strconv.Itoa(releaseID),
+		Before:     fiber.Map{"status": status},
+		After:      fiber.Map{"status": "published", "entries": total},
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to publish release"})
+	}
+
 	if err := tx.Commit(); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to publish release"})
 	}
@@ -459,10 +590,10 @@ func PublishRankingsRelease(c *fiber.Ctx) error {
 // ------------------ helpers ------------------
 
 type parsedPasteRow struct {
-	Rank         int
-	Name         string
-	School       string
-	PreviousRank *int
+	Rank         int    `json:"rank"`
+	Name         string `json:"name"`
+	School       string `json:"school"`
+	PreviousRank *int   `json:"previousRank"`
 }
 
 // ------------------ Paste parser (Flo-friendly) ------------------
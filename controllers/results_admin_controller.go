@@ -6,6 +6,7 @@ import (
 
 	"gable-backend/database"
 	"gable-backend/internal/ingest/trackdual"
+	"gable-backend/internal/stats"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -32,7 +33,7 @@ func ImportTrackDualCSV(c *fiber.Ctx) error {
 	}
 
 	repo := trackdual.NewPostgresRepository(database.DB)
-	svc := trackdual.NewService(repo)
+	svc := trackdual.NewService(repo).WithRefresh(stats.NewStore(database.DB))
 
 	result, err := svc.Process(context.Background(), records)
 	if err != nil {
@@ -40,6 +41,24 @@ func ImportTrackDualCSV(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "ingestion failed"})
 	}
 
+	if result.BatchID != "" {
+		// The service commits its own transaction, so the entry follows it.
+		recordAuditOutsideTx(c, auditEntry{
+			Action:     "results.import.trackdual",
+			TargetType: "ingest_batch",
+			TargetID:   result.BatchID,
+			After: fiber.Map{
+				"filename":        file.Filename,
+				"rowsRead":        result.RowsRead,
+				"rowsSucceeded":   result.RowsSucceeded,
+				"rowsFailed":      result.RowsFailed,
+				"boutsInserted":   result.BoutsInserted,
+				"boutsDuplicated": result.BoutsDuplicated,
+				"status":          result.Status(),
+			},
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"rows_read":        result.RowsRead,
 		"rows_succeeded":   result.RowsSucceeded,
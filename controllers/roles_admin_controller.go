@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gable-backend/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+type RoleRow struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UserRoleGrant struct {
+	Role      string    `json:"role"`
+	GrantedBy *int      `json:"grantedBy"`
+	GrantedAt time.Time `json:"grantedAt"`
+}
+
+type AdminUserRow struct {
+	ID       int      `json:"id"`
+	Email    string   `json:"email"`
+	Verified bool     `json:"verified"`
+	Roles    []string `json:"roles"`
+}
+
+type grantRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// GET /api/admin/roles
+func ListRoles(c *fiber.Ctx) error {
+	rows, err := database.DB.Query(`
+		SELECT r.name, COALESCE(r.description, ''),
+		       COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		GROUP BY r.id
+		ORDER BY r.name
+	`)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list roles"})
+	}
+	defer rows.Close()
+
+	roles := make([]RoleRow, 0)
+	for rows.Next() {
+		var r RoleRow
+		if err := rows.Scan(&r.Name, &r.Description, pq.Array(&r.Permissions)); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read roles"})
+		}
+		roles = append(roles, r)
+	}
+
+	return c.JSON(roles)
+}
+
+// GET /api/admin/users?email=fan@example.com
+// Case-insensitive email prefix search, capped at 25 results.
+func SearchUsers(c *fiber.Ctx) error {
+	email := strings.ToLower(strings.TrimSpace(c.Query("email")))
+	if email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "email query param is required"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT u.id, u.email, u.verified,
+		       COALESCE(array_agg(r.name ORDER BY r.name) FILTER (WHERE r.name IS NOT NULL), '{}')
+		FROM users u
+		LEFT JOIN user_roles ur ON ur.user_id = u.id
+		LEFT JOIN roles r ON r.id = ur.role_id
+		WHERE lower(u.email) LIKE $1 || '%'
+		GROUP BY u.id
+		ORDER BY u.email
+		LIMIT 25
+	`, email)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to search users"})
+	}
+	defer rows.Close()
+
+	users := make([]AdminUserRow, 0)
+	for rows.Next() {
+		var u AdminUserRow
+		if err := rows.Scan(&u.ID, &u.Email, &u.Verified, pq.Array(&u.Roles)); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read users"})
+		}
+		users = append(users, u)
+	}
+
+	return c.JSON(users)
+}
+
+// GET /api/admin/users/:id/roles
+func GetUserRoles(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || userID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user id"})
+	}
+	return getUserRolesByID(c, userID)
+}
+
+func getUserRolesByID(c *fiber.Ctx, userID int) error {
+	var email string
+	err := database.DB.QueryRow(`SELECT email FROM users WHERE id = $1`, userID).Scan(&email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load user"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT r.name, ur.granted_by, ur.granted_at
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY r.name
+	`, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load roles"})
+	}
+	defer rows.Close()
+
+	grants := make([]UserRoleGrant, 0)
+	for rows.Next() {
+		var g UserRoleGrant
+		var grantedBy sql.NullInt32
+		if err := rows.Scan(&g.Role, &grantedBy, &g.GrantedAt); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read roles"})
+		}
+		if grantedBy.Valid {
+			v := int(grantedBy.Int32)
+			g.GrantedBy = &v
+		}
+		grants = append(grants, g)
+	}
+
+	return c.JSON(fiber.Map{
+		"userId": userID,
+		"email":  email,
+		"roles":  grants,
+	})
+}
+
+// POST /api/admin/users/:id/roles  {"role": "rankings_editor"}
+func GrantUserRole(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || userID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user id"})
+	}
+
+	var req grantRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid JSON"})
+	}
+	req.Role = strings.TrimSpace(req.Role)
+	if req.Role == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "role is required"})
+	}
+
+	actorID, _ := c.Locals("user_id").(int)
+
+	var roleID int
+	err = database.DB.QueryRow(`SELECT id FROM roles WHERE name = $1`, req.Role).Scan(&roleID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Role not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load role"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start transaction"})
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO user_roles (user_id, role_id, granted_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, role_id) DO NOTHING
+	`, userID, roleID, actorID)
+	if err != nil {
+		// FK violation: the user does not exist
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to grant role"})
+	}
+
+	// Re-granting a held role is a no-op and is not audited.
+	if n, _ := res.RowsAffected(); n > 0 {
+		if err := recordAudit(c, tx, auditEntry{
+			Action:     "roles.grant",
+			TargetType: "user",
+			TargetID:   strconv.Itoa(userID),
+			After:      fiber.Map{"role": req.Role},
+		}); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to grant role"})
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to grant role"})
+	}
+
+	return getUserRolesByID(c, userID)
+}
+
+// DELETE /api/admin/users/:id/roles/:role
+// The last remaining admin cannot be demoted, so the system is never locked out.
+func RevokeUserRole(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || userID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user id"})
+	}
+	role := strings.TrimSpace(c.Params("role"))
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start transaction"})
+	}
+	defer tx.Rollback()
+
+	if role == "admin" {
+		// Serialize concurrent demotions of the last admins.
+		if _, err := tx.Exec(`LOCK TABLE user_roles IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke role"})
+		}
+		var admins int
+		err := tx.QueryRow(`
+			SELECT COUNT(*)
+			FROM user_roles ur
+			JOIN roles r ON r.id = ur.role_id
+			WHERE r.name = 'admin' AND ur.user_id <> $1
+		`, userID).Scan(&admins)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke role"})
+		}
+		if admins == 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Cannot revoke the last admin"})
+		}
+	}
+
+	res, err := tx.Exec(`
+		DELETE FROM user_roles
+		WHERE user_id = $1 AND role_id = (SELECT id FROM roles WHERE name = $2)
+	`, userID, role)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke role"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User does not have this role"})
+	}
+
+	if err := recordAudit(c, tx, auditEntry{
+		Action:     "roles.revoke",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+		Before:     fiber.Map{"role": role},
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke role"})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke role"})
+	}
+
+	return getUserRolesByID(c, userID)
+}
+
+// loadUserRoles returns the role names and the union of their permissions.
+func loadUserRoles(userID int) ([]string, []string, error) {
+	roles := []string{}
+	perms := []string{}
+	err := database.DB.QueryRow(`
+		SELECT
+			COALESCE((SELECT array_agg(r.name ORDER BY r.name)
+			          FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+			          WHERE ur.user_id = $1), '{}'),
+			COALESCE((SELECT array_agg(DISTINCT rp.permission ORDER BY rp.permission)
+			          FROM user_roles ur JOIN role_permissions rp ON rp.role_id = ur.role_id
+			          WHERE ur.user_id = $1), '{}')
+	`, userID).Scan(pq.Array(&roles), pq.Array(&perms))
+	return roles, perms, err
+}
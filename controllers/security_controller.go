@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/internal/throttle"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Sign-in throttling policies. Account lockouts stay short because anyone who
+// knows an email can trigger them; per-IP limits are looser but escalate to a day.
+var (
+	loginAccountPolicy = throttle.Policy{
+		Scope:       "login:account",
+		MaxFailures: 5,
+		Window:      15 * time.Minute,
+		BaseLockout: time.Minute,
+		MaxLockout:  time.Hour,
+	}
+	loginIPPolicy = throttle.Policy{
+		Scope:       "login:ip",
+		MaxFailures: 20,
+		Window:      15 * time.Minute,
+		BaseLockout: 5 * time.Minute,
+		MaxLockout:  24 * time.Hour,
+	}
+	// Registration and resends count every attempt, successful or not.
+	registerIPPolicy = throttle.Policy{
+		Scope:       "register:ip",
+		MaxFailures: 10,
+		Window:      time.Hour,
+		BaseLockout: 15 * time.Minute,
+		MaxLockout:  24 * time.Hour,
+	}
+	resendIPPolicy = throttle.Policy{
+		Scope:       "resend:ip",
+		MaxFailures: 10,
+		Window:      time.Hour,
+		BaseLockout: 15 * time.Minute,
+		MaxLockout:  24 * time.Hour,
+	}
+)
+
+type throttlePair struct {
+	policy throttle.Policy
+	key    string
+}
+
+func throttleStore() *throttle.Store { return throttle.NewStore(database.DB) }
+
+// checkThrottle returns the longest remaining lockout across the given
+// policy/key pairs. Store errors are logged and treated as unlocked so an
+// outage of the counters does not block every sign-in.
+func checkThrottle(c *fiber.Ctx, pairs ...throttlePair) time.Duration {
+	store := throttleStore()
+	var wait time.Duration
+	for _, p := range pairs {
+		d, err := store.Check(c.Context(), p.policy, p.key)
+		if err != nil {
+			log.Printf("throttle check %s: %v", p.policy.Scope, err)
+			continue
+		}
+		if d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// recordThrottleFailure counts one failure against each pair.
+func recordThrottleFailure(c *fiber.Ctx, pairs ...throttlePair) {
+	store := throttleStore()
+	for _, p := range pairs {
+		lock, err := store.Fail(c.Context(), p.policy, p.key)
+		if err != nil {
+			log.Printf("throttle record %s: %v", p.policy.Scope, err)
+			continue
+		}
+		if lock > 0 {
+			log.Printf("throttle lockout: scope=%s key=%s duration=%s", p.policy.Scope, p.key, lock)
+		}
+	}
+}
+
+// tooManyAttempts is the single response for every throttled auth endpoint.
+func tooManyAttempts(c *fiber.Ctx, wait time.Duration) error {
+	secs := int(wait.Seconds())
+	if secs < 1 {
+		secs = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(secs))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       "Too many attempts. Please try again later.",
+		"retry_after": secs,
+	})
+}
+
+// GET /api/admin/security/lockouts
+func ListLockouts(c *fiber.Ctx) error {
+	entries, err := throttleStore().ListLocked(c.Context())
+	if err != nil {
+		log.Printf("list lockouts: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list lockouts"})
+	}
+
+	out := make([]fiber.Map, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, fiber.Map{
+			"scope":         e.Scope,
+			"key":           e.Key,
+			"lockouts":      e.Lockouts,
+			"lastFailureAt": e.LastFailure,
+			"lockedUntil":   e.LockedUntil,
+		})
+	}
+	return c.JSON(out)
+}
+
+// DELETE /api/admin/security/lockouts?scope=login:account&key=fan@example.com
+func ClearLockout(c *fiber.Ctx) error {
+	scope := strings.TrimSpace(c.Query("scope"))
+	key := strings.TrimSpace(c.Query("key"))
+	if scope == "" || key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "scope and key query params are required"})
+	}
+
+	found, err := throttleStore().Unlock(c.Context(), scope, key)
+	if err != nil {
+		log.Printf("clear lockout: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to clear lockout"})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Lockout not found"})
+	}
+	recordAuditOutsideTx(c, auditEntry{
+		Action:     "security.lockout.clear",
+		TargetType: "auth_throttle",
+		TargetID:   scope + ":" + key,
+		Before:     fiber.Map{"scope": scope, "key": key},
+	})
+	return c.JSON(fiber.Map{"cleared": true})
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/internal/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SessionRow struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// startSession creates a user_sessions row for a freshly authenticated user and
+// returns the token payload shared by every sign-in path.
+func startSession(c *fiber.Ctx, userID int, email string) (fiber.Map, error) {
+	var sessionID string
+	err := database.DB.QueryRow(`
+		INSERT INTO user_sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, '', $2, $3, now() + $4 * interval '1 second')
+		RETURNING id
+	`, userID, c.Get(fiber.HeaderUserAgent), c.IP(), int(auth.RefreshTokenTTL.Seconds())).Scan(&sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, hash, err := auth.NewRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := database.DB.Exec(
+		`UPDATE user_sessions SET refresh_token_hash = $1 WHERE id = $2`, hash, sessionID,
+	); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := auth.SignAccessToken(userID, email, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return fiber.Map{
+		"token":         accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// POST /api/gable/token/refresh
+// Exchanges a refresh token for a new access token and a rotated refresh token.
+// Presenting a refresh token that has already been rotated revokes the session.
+func RefreshSession(c *fiber.Ctx) error {
+	var data struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	sessionID, secret, ok := auth.SplitRefreshToken(data.RefreshToken)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start transaction"})
+	}
+	defer tx.Rollback()
+
+	var (
+		userID     int
+		email      string
+		storedHash string
+		revokedAt  sql.NullTime
+		expiresAt  time.Time
+	)
+	err = tx.QueryRow(`
+		SELECT s.user_id, u.email, s.refresh_token_hash, s.revoked_at, s.expires_at
+		FROM user_sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id::TEXT = $1
+		FOR UPDATE OF s
+	`, sessionID).Scan(&userID, &email, &storedHash, &revokedAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load session"})
+	}
+
+	if revokedAt.Valid || time.Now().After(expiresAt) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Session has ended"})
+	}
+
+	if auth.HashToken(secret) != storedHash {
+		// A previously rotated token was replayed: assume it was stolen and
+		// end the session for everyone holding a token from it.
+		if _, err := tx.Exec(`
+			UPDATE user_sessions
+			SET revoked_at = now(), revoked_reason = 'reuse_detected'
+			WHERE id = $1
+		`, sessionID); err == nil {
+			tx.Commit()
+		}
+		log.Printf("refresh token reuse detected: user_id=%d session=%s ip=%s", userID, sessionID, c.IP())
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Refresh token reuse detected; session revoked"})
+	}
+
+	refreshToken, hash, err := auth.NewRefreshToken(sessionID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to rotate token"})
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_sessions
+		SET refresh_token_hash = $1,
+		    last_used_at = now(),
+		    expires_at = now() + $2 * interval '1 second',
+		    ip_address = $3,
+		    user_agent = $4
+		WHERE id = $5
+	`, hash, int(auth.RefreshTokenTTL.Seconds()), c.IP(), c.Get(fiber.HeaderUserAgent), sessionID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to rotate token"})
+	}
+
+	accessToken, err := auth.SignAccessToken(userID, email, sessionID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create token"})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to rotate token"})
+	}
+
+	return c.JSON(fiber.Map{
+		"token":         accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(auth.AccessTokenTTL.Seconds()),
+	})
+}
+
+// POST /api/gable/logout
+// Ends the session the current access token belongs to.
+func Logout(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	sessionID, _ := c.Locals("session_id").(string)
+
+	_, err := database.DB.Exec(`
+		UPDATE user_sessions
+		SET revoked_at = now(), revoked_reason = 'logout'
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, sessionID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to log out"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Logged out"})
+}
+
+// GET /api/gable/user/sessions
+// Lists the caller's active sessions, newest activity first.
+func ListSessions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	currentID, _ := c.Locals("session_id").(string)
+
+	rows, err := database.DB.Query(`
+		SELECT id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, last_used_at, expires_at
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()
+		ORDER BY last_used_at DESC
+	`, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list sessions"})
+	}
+	defer rows.Close()
+
+	sessions := make([]SessionRow, 0)
+	for rows.Next() {
+		var s SessionRow
+		if err := rows.Scan(&s.ID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read sessions"})
+		}
+		s.Current = s.ID == currentID
+		sessions = append(sessions, s)
+	}
+
+	return c.JSON(sessions)
+}
+
+// DELETE /api/gable/user/sessions/:id
+// Revokes one of the caller's sessions (e.g. a lost device).
+func RevokeSession(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	sessionID := strings.TrimSpace(c.Params("id"))
+	res, err := database.DB.Exec(`
+		UPDATE user_sessions
+		SET revoked_at = now(), revoked_reason = 'user_revoked'
+		WHERE id::TEXT = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, sessionID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke session"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// DELETE /api/gable/user/sessions
+// Logs the caller out everywhere, including the current device.
+func RevokeAllSessions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	res, err := database.DB.Exec(`
+		UPDATE user_sessions
+		SET revoked_at = now(), revoked_reason = 'logout_all'
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke sessions"})
+	}
+	n, _ := res.RowsAffected()
+
+	return c.JSON(fiber.Map{"ok": true, "revoked": n})
+}
@@ -1,32 +1,88 @@
 package controllers
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"gable-backend/database"
-	"gable-backend/mail"
-	"gable-backend/models"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"gable-backend/database"
+	"gable-backend/internal/auth"
+	"gable-backend/mail"
+	"gable-backend/models"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func generateVerificationToken() (string, error) {
-	b := make([]byte, 16)
-	_, err := rand.Read(b)
+const (
+	verificationTokenTTL       = 24 * time.Hour
+	verificationResendCooldown = time.Minute
+	maxVerificationSendsPerDay = 5
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// issueVerificationToken stores the hash of a new verification token for the
+// user, expires any older unused tokens, and returns the raw token for the link.
+func issueVerificationToken(db execer, userID int) (string, error) {
+	token, err := auth.GenerateToken(32)
 	if err != nil {
 		return "", err
 	}
-	return hex.EncodeToString(b), nil
+
+	if _, err := db.Exec(`
+		UPDATE email_verification_tokens
+		SET expires_at = LEAST(expires_at, now())
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO email_verification_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, now() + $3 * interval '1 second')
+	`, userID, auth.HashToken(token), int(verificationTokenTTL.Seconds()))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyPassword spends the same bcrypt work as a real check so unknown
+// emails cannot be told apart from wrong passwords by response time.
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("gable-dummy-password"), 14)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func verificationURL(token, email string) string {
+	return fmt.Sprintf("%s/verify-email?token=%s&email=%s",
+		os.Getenv("FRONTEND_URL"),
+		url.QueryEscape(token),
+		url.QueryEscape(email))
 }
 
 func Register(c *fiber.Ctx) error {
@@ -37,29 +93,50 @@ func Register(c *fiber.Ctx) error {
 	if err := c.BodyParser(&data); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid input"})
 	}
+	data.Email = normalizeEmail(data.Email)
+
+	ipKey := throttlePair{registerIPPolicy, c.IP()}
+	if wait := checkThrottle(c, ipKey); wait > 0 {
+		return tooManyAttempts(c, wait)
+	}
+	recordThrottleFailure(c, ipKey)
+
+	registeredResponse := fiber.Map{
+		"message":              "User registered successfully. Please check your email to verify your account.",
+		"requiresVerification": true,
+	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(data.Password), 14)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to hash password"})
 	}
 
-	token, err := generateVerificationToken()
+	// The account, its verification token and the queued email commit
+	// together, so a failed send is retried by the outbox worker instead of
+	// leaving the user with no link.
+	tx, err := database.DB.Begin()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate verification token"})
+		return c.Status(500).JSON(fiber.Map{"error": "Could not create"})
 	}
-
-	result := database.DB.QueryRow(`
-		INSERT INTO users (email, password_hash, verified, verification_token)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id
-		`, data.Email, string(hash), false, token)
+	defer tx.Rollback()
 
 	var userID int
-	if err = result.Scan(&userID); err != nil {
+	err = tx.QueryRow(`
+		INSERT INTO users (email, password_hash, verified)
+		VALUES ($1, $2, $3)
+		RETURNING id
+		`, data.Email, string(hash), false).Scan(&userID)
+	if err != nil {
+		// An existing email gets the same answer as a new one so registration
+		// cannot be used to discover accounts.
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return c.JSON(registeredResponse)
+		}
 		return c.Status(500).JSON(fiber.Map{"error": "Could not create"})
 	}
 
-	_, err = database.DB.Exec(`
+	_, err = tx.Exec(`
 		INSERT INTO user_stats (user_id, win_distribution)
 		VALUES ($1, '{}'::jsonb)
 		`, userID)
@@ -68,48 +145,96 @@ func Register(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create user_stats"})
 	}
 
-	verificationURL := fmt.Sprintf("%s/verify-email?token=%s&email=%s",
-		os.Getenv("FRONTEND_URL"),
-		url.QueryEscape(token),
-		url.QueryEscape(data.Email))
-
-	err = mail.SendVerificationEmail(data.Email, verificationURL)
+	token, err := issueVerificationToken(tx, userID)
 	if err != nil {
-		log.Printf("Failed to send verification email: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate verification token"})
 	}
 
-	return c.JSON(fiber.Map{
-		"message":              "User registered successfully. Please check your email to verify your account.",
-		"requiresVerification": true,
-	})
+	if err := mail.EnqueueVerificationEmail(tx, data.Email, verificationURL(token, data.Email)); err != nil {
+		log.Printf("queue verification email: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Could not create"})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Could not create"})
+	}
+
+	return c.JSON(registeredResponse)
 }
 
+// VerifyEmail consumes a verification token sent in the POST body. The query
+// string is still read as a fallback for clients that forward the link as-is.
+// Unknown, already-used and expired tokens get distinct codes so the frontend
+// can offer the right next step, without revealing which account they belong to.
 func VerifyEmail(c *fiber.Ctx) error {
-	token := c.Query("token")
-	email := c.Query("email")
-
-	if token == "" || email == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid verification link"})
+	var data struct {
+		Token string `json:"token"`
+	}
+	_ = c.BodyParser(&data)
+	token := strings.TrimSpace(data.Token)
+	if token == "" {
+		token = strings.TrimSpace(c.Query("token"))
+	}
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid verification link", "code": "token_invalid"})
 	}
 
-	result, err := database.DB.Exec(`
-        UPDATE users 
-        SET verified = true, verification_token = NULL 
-        WHERE email = $1 AND verification_token = $2 AND verified = false
-    `, email, token)
-
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Verification failed"})
+	}
+	defer tx.Rollback()
+
+	var (
+		userID    int
+		expiresAt time.Time
+		usedAt    sql.NullTime
+	)
+	err = tx.QueryRow(`
+		SELECT user_id, expires_at, used_at
+		FROM email_verification_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, auth.HashToken(token)).Scan(&userID, &expiresAt, &usedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid verification link", "code": "token_invalid"})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Verification failed"})
 	}
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or expired verification link"})
+	if usedAt.Valid {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "This verification link has already been used", "code": "token_used"})
+	}
+	if time.Now().After(expiresAt) {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error":                "This verification link has expired. Please request a new one.",
+			"code":                 "token_expired",
+			"requiresVerification": true,
+		})
+	}
+
+	// Consume this token and retire any siblings so old links report "used".
+	if _, err := tx.Exec(`
+		UPDATE email_verification_tokens SET used_at = now()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Verification failed"})
+	}
+	if _, err := tx.Exec(`UPDATE users SET verified = true WHERE id = $1`, userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Verification failed"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Verification failed"})
 	}
 
 	return c.JSON(fiber.Map{"message": "Email verified successfully. You can now log in."})
 }
 
+// ResendVerification issues a fresh token (invalidating older ones). Sends are
+// limited per account by a short cooldown and a daily cap; over the limit the
+// generic response is returned without sending anything. Attempts are also
+// throttled per IP so the endpoint cannot be used to probe many addresses.
 func ResendVerification(c *fiber.Ctx) error {
 	var data struct {
 		Email string `json:"email"`
@@ -118,49 +243,68 @@ func ResendVerification(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
 	}
 
-	var verified bool
-	var token string
+	ipKey := throttlePair{resendIPPolicy, c.IP()}
+	if wait := checkThrottle(c, ipKey); wait > 0 {
+		return tooManyAttempts(c, wait)
+	}
+	recordThrottleFailure(c, ipKey)
 
+	genericResponse := fiber.Map{"message": "If your email exists in our system, a verification link has been sent"}
+
+	var userID int
+	var verified bool
 	err := database.DB.QueryRow(`
-        SELECT verified, verification_token FROM users WHERE email = $1
-    `, data.Email).Scan(&verified, &token)
+        SELECT id, verified FROM users WHERE lower(email) = $1
+    `, normalizeEmail(data.Email)).Scan(&userID, &verified)
 
 	if err != nil {
-		return c.JSON(fiber.Map{"message": "If your email exists in our system, a verification link has been sent"})
+		return c.JSON(genericResponse)
 	}
 
 	if verified {
-		return c.JSON(fiber.Map{"message": "Your email is already verified"})
+		return c.JSON(genericResponse)
 	}
 
-	if token == "" {
-		token, err = generateVerificationToken()
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate verification token"})
-		}
-
-		_, err = database.DB.Exec(`
-            UPDATE users SET verification_token = $1 WHERE email = $2
-        `, token, data.Email)
-
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update verification token"})
-		}
+	var sentToday int
+	var lastSent sql.NullTime
+	err = database.DB.QueryRow(`
+		SELECT COUNT(*), MAX(created_at)
+		FROM email_verification_tokens
+		WHERE user_id = $1 AND created_at > now() - interval '24 hours'
+	`, userID).Scan(&sentToday, &lastSent)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check verification history"})
+	}
+	if sentToday >= maxVerificationSendsPerDay ||
+		(lastSent.Valid && time.Since(lastSent.Time) < verificationResendCooldown) {
+		log.Printf("verification resend limited: user_id=%d sent_today=%d", userID, sentToday)
+		return c.JSON(genericResponse)
 	}
 
-	verificationURL := fmt.Sprintf("%s/verify-email?token=%s&email=%s",
-		os.Getenv("FRONTEND_URL"),
-		url.QueryEscape(token),
-		url.QueryEscape(data.Email))
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate verification token"})
+	}
+	defer tx.Rollback()
 
-	err = mail.SendVerificationEmail(data.Email, verificationURL)
+	token, err := issueVerificationToken(tx, userID)
 	if err != nil {
-		log.Printf("Failed to send verification email: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate verification token"})
+	}
+	if err := mail.EnqueueVerificationEmail(tx, data.Email, verificationURL(token, data.Email)); err != nil {
+		log.Printf("queue verification email: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to queue verification email"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate verification token"})
 	}
 
-	return c.JSON(fiber.Map{"message": "Verification email has been sent"})
+	return c.JSON(genericResponse)
 }
 
+// Login checks per-IP and per-account lockouts before touching the password.
+// Unknown emails and wrong passwords get the same response and the same bcrypt
+// cost, and both count as failures.
 func Login(c *fiber.Ctx) error {
 	var data struct {
 		Email    string `json:"email"`
@@ -170,17 +314,39 @@ func Login(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
 	}
 
+	accountKey := throttlePair{loginAccountPolicy, normalizeEmail(data.Email)}
+	ipKey := throttlePair{loginIPPolicy, c.IP()}
+	if wait := checkThrottle(c, accountKey, ipKey); wait > 0 {
+		return tooManyAttempts(c, wait)
+	}
+
+	invalid := func() error {
+		recordThrottleFailure(c, accountKey, ipKey)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid email or password"})
+	}
+
 	var user models.User
 	var verified bool
 	err := database.DB.QueryRow(`
-        SELECT id, email, password_hash, verified FROM users WHERE email = $1
-    `, data.Email).Scan(&user.ID, &user.Email, &user.PasswordHash, &verified)
+        SELECT id, email, password_hash, verified FROM users WHERE lower(email) = $1
+    `, normalizeEmail(data.Email)).Scan(&user.ID, &user.Email, &user.PasswordHash, &verified)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("login lookup: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Login failed"})
+		}
+		compareDummyPassword(data.Password)
+		return invalid()
 	}
 
 	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(data.Password)) != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Incorrect password"})
+		return invalid()
+	}
+
+	// The per-IP counter is left alone so one valid account cannot be used to
+	// reset it between guesses at others.
+	if err := throttleStore().Reset(c.Context(), loginAccountPolicy, accountKey.key); err != nil {
+		log.Printf("throttle reset: %v", err)
 	}
 
 	if !verified {
@@ -190,25 +356,17 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   strings.ToLower(strings.TrimSpace(user.Email)),
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
-	})
-
-	secret := os.Getenv("JWT_SECRET")
-	tokenString, err := token.SignedString([]byte(secret))
+	resp, err := startSession(c, user.ID, user.Email)
 	if err != nil {
+		log.Printf("Failed to start session: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create token"})
 	}
+	resp["user"] = fiber.Map{
+		"id":    user.ID,
+		"email": user.Email,
+	}
 
-	return c.JSON(fiber.Map{
-		"token": tokenString,
-		"user": fiber.Map{
-			"id":    user.ID,
-			"email": user.Email,
-		},
-	})
+	return c.JSON(resp)
 }
 
 func GetMe(c *fiber.Ctx) error {
@@ -219,12 +377,17 @@ func GetMe(c *fiber.Ctx) error {
 
 	var userData models.User
 	err := database.DB.QueryRow(`
-		SELECT id, email FROM users WHERE id = $1
-	`, userID).Scan(&userData.ID, &userData.Email)
+		SELECT id, email, verified, display_name FROM users WHERE id = $1
+	`, userID).Scan(&userData.ID, &userData.Email, &userData.Verified, &userData.DisplayName)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
 	}
 
+	userData.Roles, userData.Permissions, err = loadUserRoles(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load roles"})
+	}
+
 	return c.JSON(userData)
 }
 
@@ -1,11 +1,13 @@
 package controllers
 
 import (
+	"errors"
 	"log"
 	"strconv"
 	"time"
 
 	"gable-backend/database"
+	"gable-backend/internal/cache"
 	"gable-backend/models"
 
 	"github.com/gofiber/fiber/v2"
@@ -42,20 +44,10 @@ func GetWrestlersByQuery(c *fiber.Ctx) error {
 	name := c.Query("name")
 
 	if name == "" {
-		rows, err := database.DB.Query(coreWrestlerQuery + " ORDER BY w.full_name")
+		wrestlers, err := cache.Fetch(cache.Default, "game:pool", []string{cache.TopicCore}, loadWrestlerPool)
 		if err != nil {
 			return c.Status(500).SendString(err.Error())
 		}
-		defer rows.Close()
-
-		var wrestlers []models.Wrestler
-		for rows.Next() {
-			w, err := scanWrestler(rows)
-			if err != nil {
-				return c.Status(500).SendString(err.Error())
-			}
-			wrestlers = append(wrestlers, w)
-		}
 		return c.JSON(wrestlers)
 	}
 
@@ -68,17 +60,54 @@ func GetWrestlersByQuery(c *fiber.Ctx) error {
 	return c.JSON(w)
 }
 
+// loadWrestlerPool reads the whole game pool, sorted by name.
+func loadWrestlerPool() ([]models.Wrestler, error) {
+	rows, err := database.DB.Query(coreWrestlerQuery + " ORDER BY w.full_name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wrestlers []models.Wrestler
+	for rows.Next() {
+		w, err := scanWrestler(rows)
+		if err != nil {
+			return nil, err
+		}
+		wrestlers = append(wrestlers, w)
+	}
+	return wrestlers, rows.Err()
+}
+
+var (
+	errNoDailyPick    = errors.New("no daily pick")
+	errDailyNotInCore = errors.New("daily pick not in core")
+)
+
 func GetDailyWrestler(c *fiber.Ctx) error {
 	loc, _ := time.LoadLocation("America/New_York")
 	today := time.Now().In(loc).Format("2006-01-02")
 
+	w, err := cache.Fetch(cache.Default, "game:daily:"+today, []string{cache.TopicDaily, cache.TopicCore},
+		func() (models.Wrestler, error) { return loadDailyWrestler(today) })
+	switch err {
+	case nil:
+		return c.JSON(w)
+	case errNoDailyPick:
+		return c.Status(404).SendString("Wrestler not found for today")
+	default:
+		return c.Status(404).SendString("Wrestler not found")
+	}
+}
+
+func loadDailyWrestler(day string) (models.Wrestler, error) {
 	var legacyID int
 	err := database.DB.QueryRow(
-		"SELECT wrestler_id FROM daily_wrestlers WHERE day = $1::date", today,
+		"SELECT wrestler_id FROM daily_wrestlers WHERE day = $1::date", day,
 	).Scan(&legacyID)
 	if err != nil {
 		log.Println("Error querying daily_wrestlers:", err)
-		return c.Status(404).SendString("Wrestler not found for today")
+		return models.Wrestler{}, errNoDailyPick
 	}
 
 	w, err := scanWrestler(database.DB.QueryRow(
@@ -86,7 +115,7 @@ func GetDailyWrestler(c *fiber.Ctx) error {
 	))
 	if err != nil {
 		log.Println("Error fetching daily wrestler from core:", err)
-		return c.Status(404).SendString("Wrestler not found")
+		return w, errDailyNotInCore
 	}
-	return c.JSON(w)
+	return w, nil
 }
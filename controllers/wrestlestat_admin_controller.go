@@ -312,6 +312,19 @@ func EnrichRankingsRelease(c *fiber.Ctx) error {
 		okCount++
 	}
 
+	// Profiles are upserted one by one, so the entry summarizes the run after the fact.
+	recordAuditOutsideTx(c, auditEntry{
+		Action:     "rankings.release.enrich",
+		TargetType: "rankings_release",
+		TargetID:   strconv.Itoa(releaseID),
+		After: fiber.Map{
+			"season":    season,
+			"total":     len(items),
+			"succeeded": okCount,
+			"failures":  failures,
+		},
+	})
+
 	return c.JSON(fiber.Map{
 		"releaseId": releaseID,
 		"season":    season,
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// BootstrapAdmins grants the "admin" role to the verified users whose email
+// is listed in ADMIN_EMAILS, but only while no admin exists at all. It is
+// for standing up a fresh database: once there is an admin, roles are
+// managed through the admin API and a revoke is never undone on boot.
+// Unverified accounts are skipped, since anyone can register an address
+// they do not own.
+func BootstrapAdmins() {
+	emails := parseEmailList(os.Getenv("ADMIN_EMAILS"))
+	if len(emails) == 0 {
+		return
+	}
+
+	rows, err := DB.Query(`
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT u.id, r.id
+		FROM users u, roles r
+		WHERE lower(u.email) = ANY($1) AND u.verified AND r.name = 'admin'
+		  AND NOT EXISTS (
+		      SELECT 1 FROM user_roles ur JOIN roles ar ON ar.id = ur.role_id
+		      WHERE ar.name = 'admin'
+		  )
+		ON CONFLICT (user_id, role_id) DO NOTHING
+		RETURNING user_id
+	`, pq.Array(emails))
+	if err != nil {
+		log.Printf("bootstrap admins: failed to grant admin: %v", err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err == nil {
+			log.Printf("bootstrap admins: granted admin role to user %d", userID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("bootstrap admins: failed to grant admin: %v", err)
+	}
+}
+
+func parseEmailList(raw string) []string {
+	var out []string
+	for _, e := range strings.Split(raw, ",") {
+		e = strings.TrimSpace(strings.ToLower(e))
+		if e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
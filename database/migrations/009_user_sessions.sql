@@ -0,0 +1,21 @@
+-- 009_user_sessions.sql
+-- Server-side sessions backing short-lived access tokens.
+-- Each row is one signed-in device. The refresh token secret is rotated on
+-- every use and only its SHA-256 hash is stored; presenting a superseded
+-- secret for a session revokes that session (refresh-token reuse detection).
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id            INT  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash TEXT NOT NULL,
+    user_agent         TEXT,
+    ip_address         TEXT,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at         TIMESTAMPTZ NOT NULL,
+    revoked_at         TIMESTAMPTZ,
+    revoked_reason     TEXT   -- e.g. 'logout', 'user_revoked', 'logout_all', 'reuse_detected'
+);
+
+CREATE INDEX IF NOT EXISTS user_sessions_active_user_idx
+    ON user_sessions (user_id) WHERE revoked_at IS NULL;
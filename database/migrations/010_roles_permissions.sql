@@ -0,0 +1,64 @@
+-- 010_roles_permissions.sql
+-- Database-backed authorization for /api/admin.
+-- Roles bundle permissions; users are granted roles. ADMIN_EMAILS is only used
+-- at startup to grant the "admin" role to existing accounts (bootstrap).
+
+CREATE TABLE IF NOT EXISTS roles (
+    id          SERIAL PRIMARY KEY,
+    name        TEXT NOT NULL UNIQUE,
+    description TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name        TEXT PRIMARY KEY,   -- e.g. 'rankings.write'
+    description TEXT
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id    INT  NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id    INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id    INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    granted_by INT REFERENCES users(id) ON DELETE SET NULL,   -- NULL = bootstrap
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_roles_role_id_idx ON user_roles (role_id);
+
+-- ---------------------------------------------------------------------------
+-- Seed permissions
+-- ---------------------------------------------------------------------------
+INSERT INTO permissions (name, description) VALUES
+    ('rankings.read',    'View rankings releases and staging rows'),
+    ('rankings.write',   'Create releases, import/clear staging rows, resolve WrestleStat IDs, enrich'),
+    ('rankings.publish', 'Publish a rankings release'),
+    ('results.import',   'Import bout results (TrackWrestling CSV)'),
+    ('users.read',       'Look up player accounts'),
+    ('roles.manage',     'Grant and revoke roles')
+ON CONFLICT (name) DO NOTHING;
+
+-- ---------------------------------------------------------------------------
+-- Seed roles
+-- ---------------------------------------------------------------------------
+INSERT INTO roles (name, description) VALUES
+    ('admin',            'Full access to every admin endpoint'),
+    ('rankings_editor',  'Curates and publishes weekly rankings'),
+    ('results_importer', 'Imports bout and dual meet results'),
+    ('game_admin',       'Supports players of the daily game')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.name
+FROM roles r
+JOIN permissions p ON
+       r.name = 'admin'
+    OR (r.name = 'rankings_editor'  AND p.name IN ('rankings.read', 'rankings.write', 'rankings.publish'))
+    OR (r.name = 'results_importer' AND p.name IN ('results.import'))
+    OR (r.name = 'game_admin'       AND p.name IN ('users.read'))
+ON CONFLICT DO NOTHING;
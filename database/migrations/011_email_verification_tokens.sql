@@ -0,0 +1,26 @@
+-- 011_email_verification_tokens.sql
+-- Email verification tokens move out of users.verification_token into their own
+-- table. Only the SHA-256 hash of a token is stored, each token expires, and a
+-- token can be used once. Send history doubles as the resend rate limit.
+
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INT  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,   -- hex SHA-256 of the raw token
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS email_verification_tokens_user_created_idx
+    ON email_verification_tokens (user_id, created_at DESC);
+
+-- Carry over outstanding plaintext tokens so links already in inboxes keep
+-- working for one more day, then clear the plaintext column.
+INSERT INTO email_verification_tokens (user_id, token_hash, expires_at)
+SELECT id, encode(digest(verification_token, 'sha256'), 'hex'), now() + interval '24 hours'
+FROM users
+WHERE verification_token IS NOT NULL AND verification_token <> '' AND verified = false
+ON CONFLICT (token_hash) DO NOTHING;
+
+UPDATE users SET verification_token = NULL WHERE verification_token IS NOT NULL;
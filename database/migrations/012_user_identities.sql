@@ -0,0 +1,30 @@
+-- 012_user_identities.sql
+-- External OpenID Connect identities linked to users, plus the short-lived
+-- state rows that carry PKCE verifiers between /oidc/login and /oidc/callback
+-- (kept in the database so the callback can land on any instance).
+
+-- Accounts created through OIDC have a random, unusable password.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS has_password BOOLEAN NOT NULL DEFAULT true;
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       INT  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer        TEXT NOT NULL,   -- normalized issuer URL
+    subject       TEXT NOT NULL,   -- "sub" claim, stable per issuer
+    email         TEXT,            -- email reported by the provider at link time
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_login_at TIMESTAMPTZ,
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON user_identities (user_id);
+
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state_hash    TEXT PRIMARY KEY,   -- hex SHA-256 of the state parameter
+    code_verifier TEXT NOT NULL,
+    nonce         TEXT NOT NULL,
+    link_user_id  INT REFERENCES users(id) ON DELETE CASCADE,   -- set when linking to a signed-in account
+    redirect_path TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at    TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,29 @@
+-- 013_auth_throttle.sql
+-- Failure counters with progressive lockout for login, registration and
+-- verification resends. Keyed by scope ("login:ip", "login:account", ...) and
+-- the IP or normalized email, so every API instance shares the same limits.
+
+CREATE TABLE IF NOT EXISTS auth_throttle (
+    scope             TEXT NOT NULL,
+    key               TEXT NOT NULL,
+    failures          INT  NOT NULL DEFAULT 0,   -- failures in the current window
+    window_started_at TIMESTAMPTZ,
+    last_failure_at   TIMESTAMPTZ,
+    lockouts          INT  NOT NULL DEFAULT 0,   -- consecutive lockouts; each doubles the next
+    locked_until      TIMESTAMPTZ,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS auth_throttle_locked_until_idx
+    ON auth_throttle (locked_until)
+    WHERE locked_until IS NOT NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('users.manage', 'View and clear sign-in lockouts')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'users.manage'
+FROM roles r
+WHERE r.name IN ('admin', 'game_admin')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,16 @@
+-- 014_email_change_requests.sql
+-- Pending email changes. The account keeps its current address until the
+-- link sent to the new one is confirmed; only the token hash is stored.
+
+CREATE TABLE IF NOT EXISTS email_change_requests (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     INT  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    new_email   TEXT NOT NULL,
+    token_hash  TEXT NOT NULL UNIQUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS email_change_requests_user_id_idx
+    ON email_change_requests (user_id, created_at DESC);
@@ -0,0 +1,15 @@
+-- 015_user_profiles.sql
+-- Public profile fields so leaderboards and leagues never need to show emails.
+-- Display names are unique case-insensitively; users without one have no
+-- public profile.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name            TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name_changed_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_seed             TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS favorite_school_id      UUID REFERENCES core.school(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_public          BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS show_stats              BOOLEAN NOT NULL DEFAULT true;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_display_name_lower_key
+    ON users (lower(display_name))
+    WHERE display_name IS NOT NULL;
@@ -0,0 +1,20 @@
+-- 016_api_keys.sql
+-- Self-service keys for the /api/v1 platform API. The prefix identifies the
+-- key for lookup; only a SHA-256 of the secret part is stored.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      INT  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    prefix       TEXT NOT NULL UNIQUE,
+    key_hash     TEXT NOT NULL,
+    scopes       TEXT[] NOT NULL,
+    tier         TEXT NOT NULL DEFAULT 'standard',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    rotated_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ,   -- NULL = never
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
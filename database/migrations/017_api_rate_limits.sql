@@ -0,0 +1,59 @@
+-- 017_api_rate_limits.sql
+-- Quotas and usage analytics for the /api/v1 platform API.
+--   api_tiers          per-minute / per-day limits; api_keys.tier points here
+--   api_rate_counters  shared fixed-window counters (subject = key or client IP)
+--   api_usage_daily    requests per day, subject, route and status code
+
+CREATE TABLE IF NOT EXISTS api_tiers (
+    name        TEXT PRIMARY KEY,
+    per_minute  INT  NOT NULL,   -- 0 = unlimited
+    per_day     INT  NOT NULL,   -- 0 = unlimited
+    description TEXT
+);
+
+INSERT INTO api_tiers (name, per_minute, per_day, description) VALUES
+    ('anonymous',  30,   1000,   'Requests without an API key, limited per client IP'),
+    ('standard',  120,  20000,   'Default tier for self-service keys'),
+    ('partner',   600, 200000,   'Granted by an admin')
+ON CONFLICT (name) DO NOTHING;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'api_keys_tier_fkey'
+    ) THEN
+        ALTER TABLE api_keys
+            ADD CONSTRAINT api_keys_tier_fkey FOREIGN KEY (tier) REFERENCES api_tiers(name);
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS api_rate_counters (
+    subject      TEXT        NOT NULL,   -- 'key:<uuid>' or 'ip:<addr>'
+    window_kind  TEXT        NOT NULL CHECK (window_kind IN ('minute', 'day')),
+    window_start TIMESTAMPTZ NOT NULL,
+    count        INT         NOT NULL DEFAULT 0,
+    PRIMARY KEY (subject, window_kind, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS api_rate_counters_window_start_idx ON api_rate_counters (window_start);
+
+CREATE TABLE IF NOT EXISTS api_usage_daily (
+    day     DATE   NOT NULL,
+    subject TEXT   NOT NULL,   -- 'key:<uuid>' or 'anonymous'
+    route   TEXT   NOT NULL,   -- route pattern, e.g. /api/v1/wrestlers/:id
+    status  INT    NOT NULL,
+    count   BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, subject, route, status)
+);
+
+CREATE INDEX IF NOT EXISTS api_usage_daily_subject_idx ON api_usage_daily (subject, day);
+
+INSERT INTO permissions (name, description) VALUES
+    ('api.manage', 'View platform API usage and change key tiers')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'api.manage'
+FROM roles r
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
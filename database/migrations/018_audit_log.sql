@@ -0,0 +1,33 @@
+-- 018_audit_log.sql
+-- Who changed what through /api/admin. Entries are written in the same
+-- transaction as the change where the handler has one, so a rolled-back
+-- change leaves no entry.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id            BIGSERIAL PRIMARY KEY,
+    actor_user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    actor_email   TEXT,          -- snapshot; survives email changes and deletion
+    action        TEXT NOT NULL, -- dotted verb, e.g. rankings.release.publish
+    target_type   TEXT NOT NULL,
+    target_id     TEXT,
+    before        JSONB,
+    after         JSONB,
+    ip_address    TEXT,
+    user_agent    TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx      ON audit_log (actor_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_log_target_idx     ON audit_log (target_type, target_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_log_action_idx     ON audit_log (action, created_at DESC);
+
+INSERT INTO permissions (name, description) VALUES
+    ('audit.read', 'Read the admin audit log')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'audit.read'
+FROM roles r
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
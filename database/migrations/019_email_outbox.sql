@@ -0,0 +1,36 @@
+-- 019_email_outbox.sql
+-- Transactional outbox for outgoing email. Rows are written in the same
+-- transaction as the change that triggers the email and delivered by a
+-- background worker, which retries with exponential backoff and parks
+-- messages as 'dead' after too many failures.
+
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    kind            TEXT  NOT NULL,                 -- template name, e.g. verify_email
+    message         JSONB NOT NULL,                 -- mail.Message
+    status          TEXT  NOT NULL DEFAULT 'pending'
+                    CHECK (status IN ('pending', 'sending', 'sent', 'dead')),
+    attempts        INT   NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until    TIMESTAMPTZ,                    -- claim expiry while 'sending'
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at         TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS email_outbox_due_idx
+    ON email_outbox (next_attempt_at)
+    WHERE status IN ('pending', 'sending');
+
+CREATE INDEX IF NOT EXISTS email_outbox_status_idx
+    ON email_outbox (status, created_at DESC);
+
+INSERT INTO permissions (name, description) VALUES
+    ('email.manage', 'Inspect and re-queue outgoing email')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'email.manage'
+FROM roles r
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
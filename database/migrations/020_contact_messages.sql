@@ -0,0 +1,41 @@
+-- 020_contact_messages.sql
+-- Contact form inbox. Messages were previously only emailed; now they are
+-- kept with a status so admins can reply and close them from the admin UI.
+
+CREATE TABLE IF NOT EXISTS contact_messages (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INT REFERENCES users(id) ON DELETE SET NULL,
+    email      TEXT NOT NULL,        -- sender address at submission time
+    name       TEXT NOT NULL DEFAULT '',
+    message    TEXT NOT NULL,
+    status     TEXT NOT NULL DEFAULT 'new'
+               CHECK (status IN ('new', 'replied', 'closed')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    closed_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS contact_messages_status_idx
+    ON contact_messages (status, id DESC);
+
+CREATE TABLE IF NOT EXISTS contact_replies (
+    id                 BIGSERIAL PRIMARY KEY,
+    contact_message_id BIGINT NOT NULL REFERENCES contact_messages(id) ON DELETE CASCADE,
+    admin_user_id      INT REFERENCES users(id) ON DELETE SET NULL,
+    admin_email        TEXT,
+    body               TEXT NOT NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS contact_replies_message_idx
+    ON contact_replies (contact_message_id, created_at);
+
+INSERT INTO permissions (name, description) VALUES
+    ('contact.manage', 'Read, reply to and close contact messages')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'contact.manage'
+FROM roles r
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
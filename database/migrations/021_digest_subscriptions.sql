@@ -0,0 +1,19 @@
+-- 021_digest_subscriptions.sql
+-- Weekly rankings digest subscriptions. Empty filter arrays mean "everything".
+-- last_sent_week is the week (Monday, America/New_York) of the last digest
+-- sent, so a restart or a second instance never sends the same week twice.
+
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    user_id        INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    enabled        BOOLEAN NOT NULL DEFAULT true,
+    weight_classes TEXT[]  NOT NULL DEFAULT '{}',   -- core.weight_class labels
+    school_ids     UUID[]  NOT NULL DEFAULT '{}',
+    wrestler_ids   UUID[]  NOT NULL DEFAULT '{}',
+    last_sent_week DATE,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS digest_subscriptions_enabled_idx
+    ON digest_subscriptions (last_sent_week)
+    WHERE enabled;
@@ -0,0 +1,18 @@
+-- 022_notification_preferences.sql
+-- Opt-in daily reminder emails. reminder_time is in the user's time_zone;
+-- last_reminder_date is the puzzle day (America/New_York) last reminded
+-- about, so each puzzle day gets at most one reminder.
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id            INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    daily_reminder     BOOLEAN NOT NULL DEFAULT false,
+    reminder_time      TIME    NOT NULL DEFAULT '18:00',
+    time_zone          TEXT    NOT NULL DEFAULT 'America/New_York',
+    last_reminder_date DATE,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS notification_preferences_reminder_idx
+    ON notification_preferences (user_id)
+    WHERE daily_reminder;
@@ -0,0 +1,46 @@
+-- 023_slug_history.sql
+-- Remembers retired slugs so links and API clients using an old slug can be
+-- redirected to the current one. Rows are written by triggers whenever a
+-- wrestler, school or conference slug changes.
+
+CREATE TABLE IF NOT EXISTS core.slug_history (
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('wrestler', 'school', 'conference')),
+    old_slug    TEXT NOT NULL,
+    entity_id   UUID NOT NULL,
+    changed_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (entity_type, old_slug)
+);
+
+CREATE INDEX IF NOT EXISTS slug_history_entity_idx ON core.slug_history (entity_type, entity_id);
+
+-- TG_ARGV[0] is the entity_type. A slug that becomes current again (or is
+-- taken by a new row) stops redirecting.
+CREATE OR REPLACE FUNCTION core.record_slug_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.slug IS DISTINCT FROM OLD.slug THEN
+        INSERT INTO core.slug_history (entity_type, old_slug, entity_id)
+        VALUES (TG_ARGV[0], OLD.slug, OLD.id)
+        ON CONFLICT (entity_type, old_slug)
+        DO UPDATE SET entity_id = EXCLUDED.entity_id, changed_at = now();
+    END IF;
+
+    DELETE FROM core.slug_history
+    WHERE entity_type = TG_ARGV[0] AND old_slug = NEW.slug;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS wrestler_slug_history ON core.wrestler;
+CREATE TRIGGER wrestler_slug_history
+    AFTER INSERT OR UPDATE OF slug ON core.wrestler
+    FOR EACH ROW EXECUTE FUNCTION core.record_slug_change('wrestler');
+
+DROP TRIGGER IF EXISTS school_slug_history ON core.school;
+CREATE TRIGGER school_slug_history
+    AFTER INSERT OR UPDATE OF slug ON core.school
+    FOR EACH ROW EXECUTE FUNCTION core.record_slug_change('school');
+
+DROP TRIGGER IF EXISTS conference_slug_history ON core.conference;
+CREATE TRIGGER conference_slug_history
+    AFTER INSERT OR UPDATE OF slug ON core.conference
+    FOR EACH ROW EXECUTE FUNCTION core.record_slug_change('conference');
@@ -0,0 +1,10 @@
+-- 024_results_indexes.sql
+-- Lookups behind /api/v1/results: bouts by event, season and either
+-- wrestler, and dual meets by season and date.
+
+CREATE INDEX IF NOT EXISTS idx_bout_event      ON core.bout (event_id);
+CREATE INDEX IF NOT EXISTS idx_bout_season     ON core.bout (season_id);
+CREATE INDEX IF NOT EXISTS idx_bout_wrestler_a ON core.bout (wrestler_a_id);
+CREATE INDEX IF NOT EXISTS idx_bout_wrestler_b ON core.bout (wrestler_b_id);
+
+CREATE INDEX IF NOT EXISTS idx_event_season_date ON core.event (season_id, event_date DESC);
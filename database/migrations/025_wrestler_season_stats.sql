@@ -0,0 +1,15 @@
+-- 025_wrestler_season_stats.sql
+-- Per-season stat lines derived from core.bout (see internal/stats). Rows
+-- are rebuilt a season at a time after each ingest batch; the JSON shape is
+-- stats.Line.
+
+CREATE TABLE IF NOT EXISTS core.wrestler_season_stats (
+    wrestler_id UUID        NOT NULL REFERENCES core.wrestler(id) ON DELETE CASCADE,
+    season_id   UUID        NOT NULL REFERENCES core.season(id)   ON DELETE CASCADE,
+    stats       JSONB       NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (wrestler_id, season_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wrestler_season_stats_season
+    ON core.wrestler_season_stats (season_id);
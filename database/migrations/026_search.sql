@@ -0,0 +1,38 @@
+-- 026_search.sql
+-- Accent-insensitive full-text and trigram search for GET /api/v1/search.
+-- core.search_norm lower-cases and strips accents; it is IMMUTABLE so it can
+-- back expression indexes (unaccent itself is only STABLE).
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+CREATE OR REPLACE FUNCTION core.search_norm(TEXT) RETURNS TEXT
+LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT AS $$
+    SELECT lower(public.unaccent('public.unaccent'::regdictionary, $1))
+$$;
+
+-- Trigram indexes serve the similarity operators (typo tolerance).
+CREATE INDEX IF NOT EXISTS idx_wrestler_name_trgm
+    ON core.wrestler USING GIN (core.search_norm(full_name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_wrestler_alias_trgm
+    ON core.wrestler_alias USING GIN (core.search_norm(alias) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_school_name_trgm
+    ON core.school USING GIN (core.search_norm(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_school_short_name_trgm
+    ON core.school USING GIN (core.search_norm(short_name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_conference_name_trgm
+    ON core.conference USING GIN (core.search_norm(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_event_name_trgm
+    ON core.event USING GIN (core.search_norm(name) gin_trgm_ops);
+
+-- Full-text indexes serve prefix matches ("spen le" -> Spencer Lee).
+CREATE INDEX IF NOT EXISTS idx_wrestler_name_fts
+    ON core.wrestler USING GIN (to_tsvector('simple', core.search_norm(full_name)));
+CREATE INDEX IF NOT EXISTS idx_wrestler_alias_fts
+    ON core.wrestler_alias USING GIN (to_tsvector('simple', core.search_norm(alias)));
+CREATE INDEX IF NOT EXISTS idx_school_name_fts
+    ON core.school USING GIN (to_tsvector('simple', core.search_norm(name || ' ' || COALESCE(short_name, ''))));
+CREATE INDEX IF NOT EXISTS idx_conference_name_fts
+    ON core.conference USING GIN (to_tsvector('simple', core.search_norm(name)));
+CREATE INDEX IF NOT EXISTS idx_event_name_fts
+    ON core.event USING GIN (to_tsvector('simple', core.search_norm(name)));
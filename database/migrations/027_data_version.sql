@@ -0,0 +1,50 @@
+-- 027_data_version.sql
+-- A single counter bumped whenever published platform data changes: an
+-- ingest batch completes, a ranking snapshot is published or unpublished, or
+-- stat lines are recomputed. /api/v1 ETags are derived from it (see
+-- internal/httpcache). Bumps happen inside the writing transaction, so the
+-- new version is visible exactly when the data is.
+
+CREATE TABLE IF NOT EXISTS core.data_version (
+    id         BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    version    BIGINT      NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO core.data_version (id, version) VALUES (true, 1)
+ON CONFLICT (id) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION core.bump_data_version() RETURNS trigger AS $$
+BEGIN
+    UPDATE core.data_version SET version = version + 1, updated_at = now();
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Batches finish as 'completed' or 'completed_with_errors'.
+DROP TRIGGER IF EXISTS ingest_batch_data_version ON core.ingest_batch;
+CREATE TRIGGER ingest_batch_data_version
+    AFTER UPDATE OF status ON core.ingest_batch
+    FOR EACH ROW
+    WHEN (NEW.status LIKE 'completed%' AND OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION core.bump_data_version();
+
+DROP TRIGGER IF EXISTS ranking_snapshot_publish_data_version ON core.ranking_snapshot;
+CREATE TRIGGER ranking_snapshot_publish_data_version
+    AFTER UPDATE OF status ON core.ranking_snapshot
+    FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status AND 'published' IN (OLD.status, NEW.status))
+    EXECUTE FUNCTION core.bump_data_version();
+
+DROP TRIGGER IF EXISTS ranking_snapshot_insert_data_version ON core.ranking_snapshot;
+CREATE TRIGGER ranking_snapshot_insert_data_version
+    AFTER INSERT ON core.ranking_snapshot
+    FOR EACH ROW
+    WHEN (NEW.status = 'published')
+    EXECUTE FUNCTION core.bump_data_version();
+
+-- Stats are refreshed after the import transaction commits.
+DROP TRIGGER IF EXISTS wrestler_season_stats_data_version ON core.wrestler_season_stats;
+CREATE TRIGGER wrestler_season_stats_data_version
+    AFTER INSERT OR UPDATE OR DELETE ON core.wrestler_season_stats
+    FOR EACH STATEMENT EXECUTE FUNCTION core.bump_data_version();
@@ -0,0 +1,48 @@
+-- 028_cache_notify.sql
+-- Announces committed changes to the in-process caches (internal/cache).
+-- Each trigger sends the affected topic on the gable_cache channel; NOTIFY
+-- is delivered on commit and duplicates within a transaction are collapsed,
+-- so a large ingest sends each topic once.
+
+CREATE OR REPLACE FUNCTION core.notify_cache() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('gable_cache', TG_ARGV[0]);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    t RECORD;
+BEGIN
+    FOR t IN
+        SELECT * FROM (VALUES
+            ('core.wrestler',                 'core'),
+            ('core.wrestler_season',          'core'),
+            ('core.school',                   'core'),
+            ('core.conference',               'core'),
+            ('core.school_conference_season', 'core'),
+            ('core.season',                   'core'),
+            ('core.weight_class',             'core'),
+            ('core.ranking_source',           'rankings'),
+            ('core.ranking_snapshot',         'rankings'),
+            ('core.ranking_entry',            'rankings'),
+            ('public.daily_wrestlers',        'daily')
+        ) AS v(tbl, topic)
+    LOOP
+        EXECUTE format('DROP TRIGGER IF EXISTS notify_cache ON %s', t.tbl);
+        EXECUTE format(
+            'CREATE TRIGGER notify_cache AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON %s
+             FOR EACH STATEMENT EXECUTE FUNCTION core.notify_cache(%L)',
+            t.tbl, t.topic);
+    END LOOP;
+END$$;
+
+-- Let instances re-read core.data_version (027) as soon as it is bumped.
+CREATE OR REPLACE FUNCTION core.bump_data_version() RETURNS trigger AS $$
+BEGIN
+    UPDATE core.data_version SET version = version + 1, updated_at = now();
+    PERFORM pg_notify('gable_cache', 'version');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
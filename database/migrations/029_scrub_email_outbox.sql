@@ -0,0 +1,10 @@
+-- 029_scrub_email_outbox.sql
+-- Outbox bodies carry live verification, email-change and unsubscribe links.
+-- The worker now drops them once a message is sent; scrub the rows stored
+-- before that. Dead messages keep theirs so they can be re-queued. To and
+-- subject are kept for the admin listing.
+
+UPDATE email_outbox
+SET message = message - 'text' - 'html' - 'headers'
+WHERE status = 'sent'
+  AND (message ? 'text' OR message ? 'html' OR message ? 'headers');
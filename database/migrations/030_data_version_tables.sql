@@ -0,0 +1,51 @@
+-- 030_data_version_tables.sql
+-- 027 only bumped core.data_version on batch completion, snapshot publishing
+-- and stats writes, but CLI ingests and admin edits write core tables
+-- directly, leaving /api/v1 ETags pointing at stale bodies. Bump on any
+-- write to a table v1 responses read from.
+--
+-- A transaction bumps the version once however many statements it runs:
+-- later statements see their own txid and skip the update.
+
+ALTER TABLE core.data_version ADD COLUMN IF NOT EXISTS bumped_txid BIGINT;
+
+CREATE OR REPLACE FUNCTION core.bump_data_version() RETURNS trigger AS $$
+BEGIN
+    UPDATE core.data_version
+    SET version = version + 1, updated_at = now(), bumped_txid = txid_current()
+    WHERE bumped_txid IS DISTINCT FROM txid_current();
+    IF FOUND THEN
+        PERFORM pg_notify('gable_cache', 'version');
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    tbl TEXT;
+BEGIN
+    FOREACH tbl IN ARRAY ARRAY[
+        'core.wrestler',
+        'core.wrestler_alias',
+        'core.wrestler_season',
+        'core.school',
+        'core.conference',
+        'core.school_conference_season',
+        'core.season',
+        'core.weight_class',
+        'core.slug_history',
+        'core.ranking_source',
+        'core.ranking_snapshot',
+        'core.ranking_entry',
+        'core.event',
+        'core.bout'
+    ]
+    LOOP
+        EXECUTE format('DROP TRIGGER IF EXISTS data_version ON %s', tbl);
+        EXECUTE format(
+            'CREATE TRIGGER data_version AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON %s
+             FOR EACH STATEMENT EXECUTE FUNCTION core.bump_data_version()',
+            tbl);
+    END LOOP;
+END$$;
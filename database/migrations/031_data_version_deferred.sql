@@ -0,0 +1,75 @@
+-- 031_data_version_deferred.sql
+-- The 027 and 030 triggers bumped core.data_version at the first core write
+-- of a transaction and held its row lock until commit, so every core writer
+-- ran one at a time behind the longest (an ingest batch), and two writers
+-- locking rows in opposite order around the version row could deadlock.
+--
+-- Bump from deferred constraint triggers instead: the version row is only
+-- locked while the transaction commits, after all its other writes, and the
+-- new version still becomes visible with the data. Constraint triggers are
+-- per row, so a transaction-local setting skips every bump after the first.
+
+CREATE OR REPLACE FUNCTION core.bump_data_version() RETURNS trigger AS $$
+BEGIN
+    IF current_setting('gable.data_version_bumped', true) = 'on' THEN
+        RETURN NULL;
+    END IF;
+    PERFORM set_config('gable.data_version_bumped', 'on', true);
+    UPDATE core.data_version SET version = version + 1, updated_at = now();
+    PERFORM pg_notify('gable_cache', 'version');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE core.data_version DROP COLUMN IF EXISTS bumped_txid;
+
+DROP TRIGGER IF EXISTS ingest_batch_data_version ON core.ingest_batch;
+CREATE CONSTRAINT TRIGGER ingest_batch_data_version
+    AFTER UPDATE OF status ON core.ingest_batch
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+    WHEN (NEW.status LIKE 'completed%' AND OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION core.bump_data_version();
+
+-- Snapshot status changes are covered by ranking_snapshot's table trigger.
+DROP TRIGGER IF EXISTS ranking_snapshot_publish_data_version ON core.ranking_snapshot;
+DROP TRIGGER IF EXISTS ranking_snapshot_insert_data_version ON core.ranking_snapshot;
+
+DO $$
+DECLARE
+    tbl TEXT;
+BEGIN
+    FOREACH tbl IN ARRAY ARRAY[
+        'core.wrestler',
+        'core.wrestler_alias',
+        'core.wrestler_season',
+        'core.wrestler_season_stats',
+        'core.school',
+        'core.conference',
+        'core.school_conference_season',
+        'core.season',
+        'core.weight_class',
+        'core.slug_history',
+        'core.ranking_source',
+        'core.ranking_snapshot',
+        'core.ranking_entry',
+        'core.event',
+        'core.bout'
+    ]
+    LOOP
+        EXECUTE format('DROP TRIGGER IF EXISTS data_version ON %s', tbl);
+        EXECUTE format('DROP TRIGGER IF EXISTS wrestler_season_stats_data_version ON %s', tbl);
+        EXECUTE format(
+            'CREATE CONSTRAINT TRIGGER data_version AFTER INSERT OR UPDATE OR DELETE ON %s
+             DEFERRABLE INITIALLY DEFERRED
+             FOR EACH ROW EXECUTE FUNCTION core.bump_data_version()',
+            tbl);
+        -- Constraint triggers cannot fire on TRUNCATE, which is rare and
+        -- takes an exclusive lock on the table anyway.
+        EXECUTE format('DROP TRIGGER IF EXISTS data_version_truncate ON %s', tbl);
+        EXECUTE format(
+            'CREATE TRIGGER data_version_truncate AFTER TRUNCATE ON %s
+             FOR EACH STATEMENT EXECUTE FUNCTION core.bump_data_version()',
+            tbl);
+    END LOOP;
+END$$;
@@ -0,0 +1,5 @@
+-- 032_users_email_lower.sql
+-- Sign-in, verification resends, email changes and SSO all match addresses
+-- case-insensitively with lower(email).
+
+CREATE INDEX IF NOT EXISTS users_email_lower_idx ON users (lower(email));
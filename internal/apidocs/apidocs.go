@@ -0,0 +1,16 @@
+// Package apidocs embeds the OpenAPI document for /api/v1 and the page that
+// renders it. routes/platform_test.go keeps the document in step with the
+// registered routes.
+package apidocs
+
+import _ "embed"
+
+// Spec is the OpenAPI 3 document, served at /api/v1/openapi.json.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Page renders Spec with Redoc, served at /api/v1/docs.
+//
+//go:embed docs.html
+var Page []byte
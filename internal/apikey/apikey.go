@@ -0,0 +1,111 @@
+// Package apikey generates and parses platform API keys.
+//
+// A key looks like gbl_<prefix>_<secret>. The prefix is stored in clear and
+// indexed so a presented key can be found without scanning; only a SHA-256
+// of the secret is stored, so a database leak does not expose usable keys.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+)
+
+const (
+	keyPrefix    = "gbl_"
+	prefixBytes  = 4  // 8 hex chars
+	secretBytes  = 24 // 48 hex chars
+	maxKeyLength = 128
+)
+
+// Scopes a key can be granted. Each /api/v1 route requires exactly one.
+const (
+	ScopeWrestlers = "wrestlers:read"
+	ScopeSchools   = "schools:read" // schools, conferences, seasons
+	ScopeRankings  = "rankings:read"
+	ScopeResults   = "results:read"
+)
+
+// AllScopes is granted when a key is created without an explicit list.
+var AllScopes = []string{ScopeRankings, ScopeResults, ScopeSchools, ScopeWrestlers}
+
+var ErrInvalidScope = errors.New("unknown scope")
+
+// Key is a freshly generated key. Full is shown to the owner once.
+type Key struct {
+	Full   string
+	Prefix string
+	Hash   string
+}
+
+// Generate returns a new random key.
+func Generate() (Key, error) {
+	p := make([]byte, prefixBytes)
+	s := make([]byte, secretBytes)
+	if _, err := rand.Read(p); err != nil {
+		return Key{}, err
+	}
+	if _, err := rand.Read(s); err != nil {
+		return Key{}, err
+	}
+	prefix := hex.EncodeToString(p)
+	secret := hex.EncodeToString(s)
+	return Key{
+		Full:   keyPrefix + prefix + "_" + secret,
+		Prefix: prefix,
+		Hash:   HashSecret(secret),
+	}, nil
+}
+
+// Parse splits a presented key into its lookup prefix and secret.
+func Parse(key string) (prefix, secret string, ok bool) {
+	if len(key) > maxKeyLength || !strings.HasPrefix(key, keyPrefix) {
+		return "", "", false
+	}
+	prefix, secret, found := strings.Cut(key[len(keyPrefix):], "_")
+	if !found || len(prefix) != prefixBytes*2 || len(secret) != secretBytes*2 {
+		return "", "", false
+	}
+	return prefix, secret, true
+}
+
+// HashSecret returns the stored form of a key secret.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches reports whether secret hashes to storedHash, in constant time.
+func Matches(secret, storedHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashSecret(secret)), []byte(storedHash)) == 1
+}
+
+// NormalizeScopes validates, de-duplicates and sorts a requested scope list.
+// An empty list means all scopes.
+func NormalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return append([]string(nil), AllScopes...), nil
+	}
+	known := map[string]bool{}
+	for _, s := range AllScopes {
+		known[s] = true
+	}
+	seen := map[string]bool{}
+	out := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if !known[s] {
+			return nil, ErrInvalidScope
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -0,0 +1,49 @@
+package apikey
+
+import "testing"
+
+func TestGenerateParseMatch(t *testing.T) {
+	k, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	prefix, secret, ok := Parse(k.Full)
+	if !ok {
+		t.Fatalf("Parse rejected a generated key: %q", k.Full)
+	}
+	if prefix != k.Prefix {
+		t.Fatalf("prefix mismatch: got %q, want %q", prefix, k.Prefix)
+	}
+	if !Matches(secret, k.Hash) {
+		t.Fatalf("secret does not match stored hash")
+	}
+	if Matches(secret+"0", k.Hash) {
+		t.Fatalf("tampered secret matched stored hash")
+	}
+
+	for _, bad := range []string{"", "gbl_", "abc_" + k.Full[4:], k.Full + "extra", "gbl_short_secret"} {
+		if _, _, ok := Parse(bad); ok {
+			t.Errorf("Parse accepted malformed key %q", bad)
+		}
+	}
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	got, err := NormalizeScopes([]string{"wrestlers:read", " rankings:read", "wrestlers:read"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != ScopeRankings || got[1] != ScopeWrestlers {
+		t.Fatalf("unexpected scopes: %v", got)
+	}
+
+	all, _ := NormalizeScopes(nil)
+	if len(all) != len(AllScopes) {
+		t.Fatalf("empty request should grant all scopes, got %v", all)
+	}
+
+	if _, err := NormalizeScopes([]string{"admin"}); err != ErrInvalidScope {
+		t.Fatalf("expected ErrInvalidScope, got %v", err)
+	}
+}
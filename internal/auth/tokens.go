@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// AccessTokenTTL is the lifetime of the JWT sent as a Bearer token.
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long a session stays usable without a refresh.
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// Claims is the subset of access-token claims the API relies on.
+type Claims struct {
+	UserID    int
+	Email     string
+	SessionID string
+}
+
+// GenerateToken returns n cryptographically random bytes, hex-encoded.
+func GenerateToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the hex SHA-256 digest of a token. Only digests are
+// persisted; the raw token is handed to the client once.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignAccessToken issues a short-lived HS256 JWT bound to a user session.
+func SignAccessToken(userID int, email, sessionID string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"email":   strings.ToLower(strings.TrimSpace(email)),
+		"sid":     sessionID,
+		"exp":     time.Now().Add(AccessTokenTTL).Unix(),
+	})
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+// ParseAccessToken verifies the signature and expiry of an access token and
+// extracts its claims. Tokens without a session id are rejected.
+func ParseAccessToken(raw string) (Claims, error) {
+	token, err := jwt.Parse(raw, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil || token == nil || !token.Valid {
+		return Claims{}, errors.New("invalid or expired token")
+	}
+
+	mc, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Claims{}, errors.New("invalid token claims")
+	}
+
+	var claims Claims
+	userID, ok := mc["user_id"].(float64)
+	if !ok {
+		return Claims{}, errors.New("missing user ID in token")
+	}
+	claims.UserID = int(userID)
+
+	email, _ := mc["email"].(string)
+	claims.Email = strings.ToLower(strings.TrimSpace(email))
+	if claims.Email == "" {
+		return Claims{}, errors.New("missing email in token")
+	}
+
+	claims.SessionID, _ = mc["sid"].(string)
+	if claims.SessionID == "" {
+		return Claims{}, errors.New("missing session in token")
+	}
+	return claims, nil
+}
+
+// NewRefreshToken returns a refresh token of the form "<sessionID>.<secret>"
+// together with the hash of its secret part. Embedding the session id lets a
+// replayed, already-rotated token be traced back to its session.
+func NewRefreshToken(sessionID string) (token, hash string, err error) {
+	secret, err := GenerateToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return sessionID + "." + secret, HashToken(secret), nil
+}
+
+// SplitRefreshToken separates a refresh token into its session id and secret.
+func SplitRefreshToken(token string) (sessionID, secret string, ok bool) {
+	sessionID, secret, ok = strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || sessionID == "" || secret == "" {
+		return "", "", false
+	}
+	return sessionID, secret, true
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestAccessTokenRoundTrip(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	raw, err := SignAccessToken(42, " User@Example.com ", "sess-1")
+	if err != nil {
+		t.Fatalf("SignAccessToken returned error: %v", err)
+	}
+
+	claims, err := ParseAccessToken(raw)
+	if err != nil {
+		t.Fatalf("ParseAccessToken returned error: %v", err)
+	}
+	if claims.UserID != 42 || claims.Email != "user@example.com" || claims.SessionID != "sess-1" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	t.Setenv("JWT_SECRET", "other-secret")
+	if _, err := ParseAccessToken(raw); err == nil {
+		t.Fatalf("expected error for token signed with a different secret")
+	}
+}
+
+func TestRefreshTokenSplit(t *testing.T) {
+	token, hash, err := NewRefreshToken("3f1c9a4e-0000-4000-8000-000000000000")
+	if err != nil {
+		t.Fatalf("NewRefreshToken returned error: %v", err)
+	}
+
+	sessionID, secret, ok := SplitRefreshToken(token)
+	if !ok {
+		t.Fatalf("expected token %q to split", token)
+	}
+	if sessionID != "3f1c9a4e-0000-4000-8000-000000000000" {
+		t.Fatalf("unexpected session id %q", sessionID)
+	}
+	if HashToken(secret) != hash {
+		t.Fatalf("hash of secret does not match returned hash")
+	}
+
+	for _, bad := range []string{"", "no-dot", ".secret", "session."} {
+		if _, _, ok := SplitRefreshToken(bad); ok {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// Package cache is an in-process read-through cache for hot queries (the
+// game pool, the daily target, rankings pages, school rosters).
+//
+// Entries are filed under topics. Database triggers announce committed
+// changes with NOTIFY on Channel, the payload naming the topic, and every
+// instance listening drops that topic's entries, so instances stay
+// consistent without a shared cache service. If the listener connection
+// drops, notifications may have been missed and everything is flushed. A TTL
+// bounds staleness should a notification never arrive.
+//
+// Memory is bounded by the approximate size of the cached values (their JSON
+// encoding, which is also what handlers send); past it the least recently
+// used entries are evicted.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel is the LISTEN/NOTIFY channel; see 028_cache_notify.sql.
+const Channel = "gable_cache"
+
+// Topics, matching the trigger payloads.
+const (
+	TopicCore     = "core"     // wrestlers, schools, conferences, seasons, weights
+	TopicRankings = "rankings" // ranking sources, snapshots and entries
+	TopicDaily    = "daily"    // daily_wrestlers
+	TopicVersion  = "version"  // core.data_version was bumped
+)
+
+const (
+	DefaultTTL      = 10 * time.Minute
+	DefaultMaxBytes = 64 << 20
+	pingInterval    = 90 * time.Second
+)
+
+// Default is the process-wide cache used by handlers.
+var Default = New(DefaultTTL, DefaultMaxBytes)
+
+type entry struct {
+	key     string
+	value   interface{}
+	topics  []string
+	size    int
+	expires time.Time
+}
+
+type Cache struct {
+	ttl      time.Duration
+	maxBytes int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // of *entry
+	lru     *list.List               // most recently used at the front
+	bytes   int
+	gen     map[string]uint64 // per topic, bumped on invalidation
+	flushes uint64
+	hooks   []func(topic string)
+}
+
+func New(ttl time.Duration, maxBytes int) *Cache {
+	return &Cache{
+		ttl:      ttl,
+		maxBytes: maxBytes,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+		gen:      map[string]uint64{},
+	}
+}
+
+// Fetch returns the cached value for key, or calls load and caches its
+// result under topics. Errors are not cached. A value loaded while one of
+// its topics was invalidated is returned but not stored, since it may
+// predate the change.
+func Fetch[T any](c *Cache, key string, topics []string, load func() (T, error)) (T, error) {
+	if v, ok := c.get(key); ok {
+		return v.(T), nil
+	}
+	stamp := c.stamp(topics)
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+	if raw, err := json.Marshal(v); err == nil {
+		c.put(key, topics, v, len(raw), stamp)
+	}
+	return v, nil
+}
+
+func (c *Cache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return e.value, true
+}
+
+// stamp captures the generations of topics before a load.
+func (c *Cache) stamp(topics []string) []uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := []uint64{c.flushes}
+	for _, t := range topics {
+		s = append(s, c.gen[t])
+	}
+	return s
+}
+
+// put stores value, evicting least recently used entries to stay within
+// maxBytes. A value larger than the whole budget is not stored.
+func (c *Cache) put(key string, topics []string, value interface{}, size int, stamp []uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stamp[0] != c.flushes {
+		return
+	}
+	for i, t := range topics {
+		if c.gen[t] != stamp[i+1] {
+			return
+		}
+	}
+
+	if size > c.maxBytes {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	for c.bytes+size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+	e := &entry{key: key, value: value, topics: topics, size: size, expires: time.Now().Add(c.ttl)}
+	c.entries[key] = c.lru.PushFront(e)
+	c.bytes += size
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.entries, e.key)
+	c.bytes -= e.size
+}
+
+// Invalidate drops every entry filed under topic.
+func (c *Cache) Invalidate(topic string) {
+	c.mu.Lock()
+	c.gen[topic]++
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		for _, t := range el.Value.(*entry).topics {
+			if t == topic {
+				c.remove(el)
+				break
+			}
+		}
+		el = next
+	}
+	hooks := c.hooks
+	c.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn(topic)
+	}
+}
+
+// Flush drops every entry. Hooks see the topic "".
+func (c *Cache) Flush() {
+	c.mu.Lock()
+	c.flushes++
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+	c.bytes = 0
+	hooks := c.hooks
+	c.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn("")
+	}
+}
+
+// OnInvalidate registers fn to run after each invalidation or flush, for
+// state kept outside the cache.
+func (c *Cache) OnInvalidate(fn func(topic string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, fn)
+}
+
+// Listen applies notifications on Channel until ctx is done. dsn is the
+// connection string; LISTEN needs a dedicated connection outside the pool.
+func (c *Cache) Listen(ctx context.Context, dsn string) {
+	l := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("cache: listener: %v", err)
+		}
+	})
+	defer l.Close()
+	if err := l.Listen(Channel); err != nil {
+		log.Printf("cache: listen %s: %v", Channel, err)
+		return
+	}
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-l.Notify:
+			if n == nil {
+				// Reconnected; anything sent meanwhile was lost.
+				c.Flush()
+				continue
+			}
+			c.Invalidate(n.Extra)
+		case <-ticker.C:
+			if err := l.Ping(); err != nil {
+				log.Printf("cache: listener ping: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func counter(n *int, value string) func() (string, error) {
+	return func() (string, error) {
+		*n++
+		return value, nil
+	}
+}
+
+func TestFetchCachesUntilInvalidated(t *testing.T) {
+	c := New(time.Minute, 10)
+	var loads int
+	for i := 0; i < 3; i++ {
+		v, err := Fetch(c, "pool", []string{TopicCore}, counter(&loads, "a"))
+		if err != nil || v != "a" {
+			t.Fatalf("Fetch = %q, %v", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("loads = %d, want 1", loads)
+	}
+
+	c.Invalidate(TopicRankings)
+	Fetch(c, "pool", []string{TopicCore}, counter(&loads, "a"))
+	if loads != 1 {
+		t.Fatalf("unrelated topic reloaded the entry")
+	}
+
+	c.Invalidate(TopicCore)
+	Fetch(c, "pool", []string{TopicCore}, counter(&loads, "a"))
+	if loads != 2 {
+		t.Fatalf("loads after invalidate = %d, want 2", loads)
+	}
+
+	c.Flush()
+	Fetch(c, "pool", []string{TopicCore}, counter(&loads, "a"))
+	if loads != 3 {
+		t.Fatalf("loads after flush = %d, want 3", loads)
+	}
+}
+
+func TestFetchDoesNotCacheErrors(t *testing.T) {
+	c := New(time.Minute, 10)
+	var loads int
+	fail := func() (int, error) {
+		loads++
+		return 0, errors.New("db down")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := Fetch(c, "daily", []string{TopicDaily}, fail); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if loads != 2 {
+		t.Fatalf("loads = %d, want 2", loads)
+	}
+}
+
+func TestInvalidateDuringLoadSkipsStore(t *testing.T) {
+	c := New(time.Minute, 10)
+	var loads int
+	Fetch(c, "rankings", []string{TopicRankings, TopicCore}, func() (string, error) {
+		loads++
+		c.Invalidate(TopicCore) // a change commits while the query runs
+		return "stale", nil
+	})
+	v, _ := Fetch(c, "rankings", []string{TopicRankings, TopicCore}, counter(&loads, "fresh"))
+	if v != "fresh" || loads != 2 {
+		t.Fatalf("got %q after %d loads; stale value was stored", v, loads)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	c := New(time.Millisecond, 100)
+	var loads int
+	Fetch(c, "a", nil, counter(&loads, "a"))
+	time.Sleep(2 * time.Millisecond)
+	Fetch(c, "a", nil, counter(&loads, "a"))
+	if loads != 2 {
+		t.Fatalf("expired entry was served")
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	c := New(time.Minute, 6) // room for two 3-byte values ("a" encoded)
+	var loads int
+	Fetch(c, "a", nil, counter(&loads, "a"))
+	Fetch(c, "b", nil, counter(&loads, "b"))
+	Fetch(c, "a", nil, counter(&loads, "a")) // a is now the most recent
+	Fetch(c, "c", nil, counter(&loads, "c")) // evicts b
+	if loads != 3 {
+		t.Fatalf("loads = %d, want 3", loads)
+	}
+
+	Fetch(c, "a", nil, counter(&loads, "a"))
+	Fetch(c, "c", nil, counter(&loads, "c"))
+	if loads != 3 {
+		t.Fatalf("a or c was evicted instead of b")
+	}
+	Fetch(c, "b", nil, counter(&loads, "b"))
+	if loads != 4 {
+		t.Fatalf("b was still cached past capacity")
+	}
+
+	Fetch(c, "big", nil, counter(&loads, "too large"))
+	Fetch(c, "big", nil, counter(&loads, "too large"))
+	if loads != 6 {
+		t.Fatalf("value larger than the budget was stored")
+	}
+	if c.bytes > 6 {
+		t.Fatalf("bytes = %d, over budget", c.bytes)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	c := New(time.Minute, 10)
+	var seen []string
+	c.OnInvalidate(func(topic string) { seen = append(seen, topic) })
+	c.Invalidate(TopicVersion)
+	c.Flush()
+	if len(seen) != 2 || seen[0] != TopicVersion || seen[1] != "" {
+		t.Fatalf("hooks saw %q", seen)
+	}
+}
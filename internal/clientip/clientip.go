@@ -0,0 +1,83 @@
+// Package clientip finds the client address behind trusted reverse proxies.
+//
+// Each proxy appends the address it received the request from to
+// X-Forwarded-For, so only the entries added by proxies we run can be
+// believed; anything to their left may have been sent by the client. The
+// client is therefore the rightmost entry that is not one of our proxies.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Trusted is a set of proxy addresses and ranges.
+type Trusted []*net.IPNet
+
+// ParseTrusted reads IPs and CIDRs, e.g. from a comma-separated env var.
+func ParseTrusted(list string) (Trusted, error) {
+	var t Trusted
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("clientip: invalid address %q", s)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			t = append(t, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("clientip: invalid range %q", s)
+		}
+		t = append(t, n)
+	}
+	return t, nil
+}
+
+// Strings returns the entries in the form fiber.Config.TrustedProxies takes.
+func (t Trusted) Strings() []string {
+	out := make([]string, len(t))
+	for i, n := range t {
+		out[i] = n.String()
+	}
+	return out
+}
+
+// Contains reports whether ip is a trusted proxy.
+func (t Trusted) Contains(ip net.IP) bool {
+	for _, n := range t {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the client address from an X-Forwarded-For value: the
+// rightmost entry that is not a trusted proxy, or "" if an entry that must
+// be read is not an address.
+func (t Trusted) Resolve(forwarded string) string {
+	parts := strings.Split(forwarded, ",")
+	var client string
+	for i := len(parts) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(parts[i]))
+		if ip == nil {
+			return ""
+		}
+		client = ip.String()
+		if !t.Contains(ip) {
+			break
+		}
+	}
+	return client
+}
@@ -0,0 +1,46 @@
+package clientip
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	trusted, err := ParseTrusted("10.0.0.0/8, 192.0.2.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		forwarded string
+		want      string
+	}{
+		{"203.0.113.9", "203.0.113.9"},
+		{"203.0.113.9, 10.1.2.3", "203.0.113.9"},
+		{"203.0.113.9, 192.0.2.7, 10.1.2.3", "203.0.113.9"},
+		// A client-supplied prefix is ignored, however it changes.
+		{"1.2.3.4, 203.0.113.9", "203.0.113.9"},
+		{"5.6.7.8, 1.2.3.4, 203.0.113.9, 10.1.2.3", "203.0.113.9"},
+		{"not-an-ip, 203.0.113.9", "203.0.113.9"},
+		{"203.0.113.9, junk", ""},
+		{"10.1.2.3", "10.1.2.3"},
+		{"2001:db8::1", "2001:db8::1"},
+	}
+	for _, tc := range cases {
+		if got := trusted.Resolve(tc.forwarded); got != tc.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tc.forwarded, got, tc.want)
+		}
+	}
+}
+
+func TestParseTrusted(t *testing.T) {
+	if _, err := ParseTrusted("10.0.0.0/33"); err == nil {
+		t.Error("accepted an invalid range")
+	}
+	if _, err := ParseTrusted("proxy.internal"); err == nil {
+		t.Error("accepted a hostname")
+	}
+	got, err := ParseTrusted(" 192.0.2.7 ,, 2001:db8::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := got.Strings(); len(s) != 2 || s[0] != "192.0.2.7/32" || s[1] != "2001:db8::/32" {
+		t.Errorf("Strings() = %v", s)
+	}
+}
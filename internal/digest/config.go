@@ -0,0 +1,31 @@
+package digest
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// TimeZone is where digest weeks start and end.
+const TimeZone = "America/New_York"
+
+// ConfigFromEnv reads DIGEST_SECRET (falling back to JWT_SECRET),
+// FRONTEND_URL and the optional PUBLIC_API_URL.
+func ConfigFromEnv() Config {
+	secret := os.Getenv("DIGEST_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	loc, err := time.LoadLocation(TimeZone)
+	if err != nil {
+		log.Printf("digest: load %s: %v; using UTC", TimeZone, err)
+		loc = time.UTC
+	}
+	return Config{
+		Secret:      []byte(secret),
+		FrontendURL: strings.TrimRight(os.Getenv("FRONTEND_URL"), "/"),
+		APIURL:      strings.TrimRight(os.Getenv("PUBLIC_API_URL"), "/"),
+		Location:    loc,
+	}
+}
@@ -0,0 +1,237 @@
+// Package digest builds the weekly rankings digest: what changed between
+// consecutive published rankings, filtered to what each subscriber follows.
+package digest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SendWeekday and SendHour are when the digest for the previous week goes
+// out, in the digest's time zone.
+const (
+	SendWeekday = time.Monday
+	SendHour    = 9
+)
+
+// Entry is one ranked wrestler.
+type Entry struct {
+	WrestlerID string `json:"wrestler_id,omitempty"` // core.wrestler id; empty if unmatched
+	Name       string `json:"name"`
+	School     string `json:"school,omitempty"`
+	SchoolSlug string `json:"school_slug,omitempty"`
+	Rank       int    `json:"rank"`
+}
+
+// List is one published ranking for one source and weight class.
+type List struct {
+	Source      string
+	WeightClass string
+	Date        string
+	Entries     []Entry
+}
+
+// Move is a wrestler ranked in both lists whose position changed.
+type Move struct {
+	Entry
+	PreviousRank int `json:"previous_rank"`
+}
+
+// Delta is how many places the wrestler climbed; negative means they fell.
+func (m Move) Delta() int { return m.PreviousRank - m.Rank }
+
+// Arrow renders the change for email bodies, e.g. "▲3" or "▼2".
+func (m Move) Arrow() string {
+	d := m.Delta()
+	if d > 0 {
+		return "▲" + strconv.Itoa(d)
+	}
+	return "▼" + strconv.Itoa(-d)
+}
+
+// Section is the change between two lists for one source and weight class.
+// Dropouts carry the last rank they held.
+type Section struct {
+	Source      string  `json:"source"`
+	WeightClass string  `json:"weight_class"`
+	Date        string  `json:"date"`
+	Movers      []Move  `json:"movers"`
+	NewEntries  []Entry `json:"new_entries"`
+	Dropouts    []Entry `json:"dropouts"`
+}
+
+// Empty reports whether nothing changed.
+func (s Section) Empty() bool {
+	return len(s.Movers) == 0 && len(s.NewEntries) == 0 && len(s.Dropouts) == 0
+}
+
+// Digest is one week's email content.
+type Digest struct {
+	WeekOf   time.Time
+	Sections []Section
+}
+
+// entryKey matches the same wrestler across lists. Unmatched legacy rows
+// fall back to name and school.
+func entryKey(e Entry) string {
+	if e.WrestlerID != "" {
+		return "id:" + e.WrestlerID
+	}
+	return "name:" + strings.ToLower(strings.TrimSpace(e.Name)) + "|" + strings.ToLower(strings.TrimSpace(e.School))
+}
+
+// Diff compares curr against prev. Movers are ordered by the size of the
+// move, biggest first; new entries and dropouts by rank.
+func Diff(prev, curr List) Section {
+	s := Section{
+		Source:      curr.Source,
+		WeightClass: curr.WeightClass,
+		Date:        curr.Date,
+		Movers:      []Move{},
+		NewEntries:  []Entry{},
+		Dropouts:    []Entry{},
+	}
+
+	before := make(map[string]Entry, len(prev.Entries))
+	for _, e := range prev.Entries {
+		before[entryKey(e)] = e
+	}
+	seen := make(map[string]bool, len(curr.Entries))
+	for _, e := range curr.Entries {
+		k := entryKey(e)
+		seen[k] = true
+		p, ok := before[k]
+		switch {
+		case !ok:
+			s.NewEntries = append(s.NewEntries, e)
+		case p.Rank != e.Rank:
+			s.Movers = append(s.Movers, Move{Entry: e, PreviousRank: p.Rank})
+		}
+	}
+	for _, e := range prev.Entries {
+		if !seen[entryKey(e)] {
+			s.Dropouts = append(s.Dropouts, e)
+		}
+	}
+
+	sort.SliceStable(s.Movers, func(i, j int) bool {
+		ai, aj := abs(s.Movers[i].Delta()), abs(s.Movers[j].Delta())
+		if ai != aj {
+			return ai > aj
+		}
+		return s.Movers[i].Rank < s.Movers[j].Rank
+	})
+	byRank := func(es []Entry) {
+		sort.SliceStable(es, func(i, j int) bool { return es[i].Rank < es[j].Rank })
+	}
+	byRank(s.NewEntries)
+	byRank(s.Dropouts)
+	return s
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// SortSections orders sections by source, then weight (heavyweight last).
+func SortSections(sections []Section) {
+	sort.SliceStable(sections, func(i, j int) bool {
+		if sections[i].Source != sections[j].Source {
+			return sections[i].Source < sections[j].Source
+		}
+		return weightOrder(sections[i].WeightClass) < weightOrder(sections[j].WeightClass)
+	})
+}
+
+func weightOrder(label string) int {
+	if n, err := strconv.Atoi(label); err == nil {
+		return n
+	}
+	return 1000
+}
+
+// Filter is what a subscriber follows. A change is included if its weight
+// class, its wrestler's school or the wrestler is followed. A zero Filter
+// includes everything.
+type Filter struct {
+	WeightClasses []string
+	SchoolSlugs   []string
+	WrestlerIDs   []string
+}
+
+// IsZero reports whether the filter follows nothing in particular.
+func (f Filter) IsZero() bool {
+	return len(f.WeightClasses) == 0 && len(f.SchoolSlugs) == 0 && len(f.WrestlerIDs) == 0
+}
+
+// Apply returns the parts of sections the filter follows, dropping sections
+// left empty.
+func (f Filter) Apply(sections []Section) []Section {
+	weights := set(f.WeightClasses)
+	schools := set(f.SchoolSlugs)
+	wrestlers := set(f.WrestlerIDs)
+	follows := func(e Entry) bool {
+		return (e.SchoolSlug != "" && schools[e.SchoolSlug]) || (e.WrestlerID != "" && wrestlers[e.WrestlerID])
+	}
+
+	out := make([]Section, 0, len(sections))
+	for _, s := range sections {
+		if !f.IsZero() && !weights[s.WeightClass] {
+			kept := Section{Source: s.Source, WeightClass: s.WeightClass, Date: s.Date,
+				Movers: []Move{}, NewEntries: []Entry{}, Dropouts: []Entry{}}
+			for _, m := range s.Movers {
+				if follows(m.Entry) {
+					kept.Movers = append(kept.Movers, m)
+				}
+			}
+			for _, e := range s.NewEntries {
+				if follows(e) {
+					kept.NewEntries = append(kept.NewEntries, e)
+				}
+			}
+			for _, e := range s.Dropouts {
+				if follows(e) {
+					kept.Dropouts = append(kept.Dropouts, e)
+				}
+			}
+			s = kept
+		}
+		if !s.Empty() {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func set(values []string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+// WeekStart returns midnight on the SendWeekday on or before t, in loc.
+func WeekStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	back := (int(t.Weekday()) - int(SendWeekday) + 7) % 7
+	d := t.AddDate(0, 0, -back)
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+}
+
+// Due reports whether the digest for the week starting at weekStart may be
+// sent at now.
+func Due(now, weekStart time.Time) bool {
+	return !now.Before(weekStart.Add(SendHour * time.Hour))
+}
+
+// Subject is the email subject for the digest of the week before weekStart.
+func Subject(weekStart time.Time) string {
+	return fmt.Sprintf("Your Weekly Rankings Digest — Week of %s", weekStart.AddDate(0, 0, -7).Format("Jan 2"))
+}
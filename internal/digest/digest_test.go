@@ -0,0 +1,150 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	prev := List{Source: "Flo", WeightClass: "125", Entries: []Entry{
+		{WrestlerID: "a", Name: "A", Rank: 1},
+		{WrestlerID: "b", Name: "B", Rank: 2},
+		{WrestlerID: "c", Name: "C", Rank: 3},
+		{Name: "Legacy Guy", School: "Iowa", Rank: 4},
+		{WrestlerID: "d", Name: "D", Rank: 5},
+	}}
+	curr := List{Source: "Flo", WeightClass: "125", Date: "2026-10-12", Entries: []Entry{
+		{WrestlerID: "b", Name: "B", Rank: 1},
+		{WrestlerID: "a", Name: "A", Rank: 2},
+		{WrestlerID: "d", Name: "D", Rank: 3},
+		{Name: "legacy guy", School: "IOWA", Rank: 4},
+		{WrestlerID: "e", Name: "E", Rank: 5},
+	}}
+
+	s := Diff(prev, curr)
+	if s.Date != "2026-10-12" || s.WeightClass != "125" {
+		t.Fatalf("unexpected section header: %+v", s)
+	}
+	// d climbed two places, so it leads; a and b tie on size and sort by rank.
+	wantMovers := []struct {
+		id       string
+		rank     int
+		previous int
+	}{{"d", 3, 5}, {"b", 1, 2}, {"a", 2, 1}}
+	if len(s.Movers) != len(wantMovers) {
+		t.Fatalf("movers = %+v", s.Movers)
+	}
+	for i, w := range wantMovers {
+		m := s.Movers[i]
+		if m.WrestlerID != w.id || m.Rank != w.rank || m.PreviousRank != w.previous {
+			t.Errorf("mover %d = %+v, want %+v", i, m, w)
+		}
+	}
+	if s.Movers[0].Arrow() != "▲2" || s.Movers[2].Arrow() != "▼1" {
+		t.Errorf("arrows = %q, %q", s.Movers[0].Arrow(), s.Movers[2].Arrow())
+	}
+	if len(s.NewEntries) != 1 || s.NewEntries[0].WrestlerID != "e" {
+		t.Errorf("new entries = %+v", s.NewEntries)
+	}
+	if len(s.Dropouts) != 1 || s.Dropouts[0].WrestlerID != "c" || s.Dropouts[0].Rank != 3 {
+		t.Errorf("dropouts = %+v", s.Dropouts)
+	}
+}
+
+func TestFilterApply(t *testing.T) {
+	sections := []Section{
+		{WeightClass: "125", Movers: []Move{{Entry: Entry{WrestlerID: "a", Rank: 1}, PreviousRank: 2}}},
+		{WeightClass: "133",
+			NewEntries: []Entry{{WrestlerID: "x", SchoolSlug: "iowa", Rank: 7}, {WrestlerID: "y", SchoolSlug: "ohio-state", Rank: 9}},
+			Dropouts:   []Entry{{WrestlerID: "z", SchoolSlug: "penn-state", Rank: 20}},
+		},
+		{WeightClass: "141"},
+	}
+
+	if got := (Filter{}).Apply(sections); len(got) != 2 {
+		t.Fatalf("zero filter kept %d sections, want the 2 non-empty ones", len(got))
+	}
+
+	got := Filter{WeightClasses: []string{"125"}, SchoolSlugs: []string{"iowa"}, WrestlerIDs: []string{"z"}}.Apply(sections)
+	if len(got) != 2 || got[0].WeightClass != "125" || len(got[0].Movers) != 1 {
+		t.Fatalf("unexpected filtered sections: %+v", got)
+	}
+	if len(got[1].NewEntries) != 1 || got[1].NewEntries[0].WrestlerID != "x" || len(got[1].Dropouts) != 1 {
+		t.Fatalf("133 should keep only followed entries: %+v", got[1])
+	}
+	// Filtering must not modify the shared sections.
+	if len(sections[1].NewEntries) != 2 {
+		t.Fatalf("Apply modified its input")
+	}
+
+	if got := (Filter{WrestlerIDs: []string{"nobody"}}).Apply(sections); len(got) != 0 {
+		t.Fatalf("expected nothing for an unmatched filter, got %+v", got)
+	}
+}
+
+func TestWeekStartAndDue(t *testing.T) {
+	loc := time.FixedZone("EST", -5*3600)
+	// Sunday evening still belongs to the week that started the previous Monday.
+	sunday := time.Date(2026, 10, 18, 20, 0, 0, 0, loc)
+	ws := WeekStart(sunday, loc)
+	if want := time.Date(2026, 10, 12, 0, 0, 0, 0, loc); !ws.Equal(want) {
+		t.Fatalf("WeekStart = %s, want %s", ws, want)
+	}
+	monday := time.Date(2026, 10, 19, 8, 59, 0, 0, loc)
+	next := WeekStart(monday, loc)
+	if next.Day() != 19 || Due(monday, next) {
+		t.Fatalf("8:59 Monday: week %s, due %v", next, Due(monday, next))
+	}
+	if !Due(monday.Add(time.Minute), next) {
+		t.Fatalf("expected digest due at 9:00 Monday")
+	}
+	if got := Subject(next); got != "Your Weekly Rankings Digest — Week of Oct 12" {
+		t.Fatalf("Subject = %q", got)
+	}
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	secret := []byte("s3cret")
+	tok := UnsubscribeToken(secret, 42)
+	if id, err := ParseUnsubscribeToken(secret, tok); err != nil || id != 42 {
+		t.Fatalf("ParseUnsubscribeToken = %d, %v", id, err)
+	}
+	for _, bad := range []string{"", "42", "43." + tok[3:], tok + "x", "abc.def"} {
+		if _, err := ParseUnsubscribeToken(secret, bad); err != ErrInvalidToken {
+			t.Errorf("ParseUnsubscribeToken(%q) err = %v, want ErrInvalidToken", bad, err)
+		}
+	}
+	if _, err := ParseUnsubscribeToken([]byte("other"), tok); err != ErrInvalidToken {
+		t.Errorf("token verified under a different secret")
+	}
+}
+
+func TestStoreEmail(t *testing.T) {
+	s := NewStore(nil, Config{Secret: []byte("k"), FrontendURL: "https://gable.example", APIURL: "https://api.example"})
+	week := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	msg, err := s.Email(Subscriber{UserID: 7, Email: "fan@example.com"}, Digest{WeekOf: week, Sections: []Section{{
+		Source: "Flo", WeightClass: "125", Date: "2026-10-14",
+		Movers:   []Move{{Entry: Entry{Name: "<b>A</b>", School: "Iowa", Rank: 2}, PreviousRank: 5}},
+		Dropouts: []Entry{{Name: "C", Rank: 20}},
+	}}})
+	if err != nil {
+		t.Fatalf("Email returned error: %v", err)
+	}
+	token := UnsubscribeToken([]byte("k"), 7)
+	if msg.Subject != "Your Weekly Rankings Digest — Week of Oct 12" || msg.To[0] != "fan@example.com" {
+		t.Fatalf("unexpected headers: %q %v", msg.Subject, msg.To)
+	}
+	for _, want := range []string{"#2 <b>A</b> (Iowa) ▲3", "C, was #20", "https://gable.example/unsubscribe?token=" + token} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("text body missing %q:\n%s", want, msg.Text)
+		}
+	}
+	if strings.Contains(msg.HTML, "<b>A</b>") {
+		t.Errorf("HTML body did not escape names")
+	}
+	if msg.Headers["List-Unsubscribe"] != "<https://api.example/api/gable/digest/unsubscribe?token="+token+">" ||
+		msg.Headers["List-Unsubscribe-Post"] != "List-Unsubscribe=One-Click" {
+		t.Errorf("unexpected unsubscribe headers: %v", msg.Headers)
+	}
+}
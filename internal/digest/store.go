@@ -0,0 +1,435 @@
+package digest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"gable-backend/mail"
+
+	"github.com/lib/pq"
+)
+
+const checkInterval = 15 * time.Minute
+
+// Config holds what the store needs beyond the database.
+type Config struct {
+	Secret      []byte         // signs unsubscribe tokens
+	FrontendURL string         // base for links in the email body
+	APIURL      string         // optional public API base; enables one-click List-Unsubscribe
+	Location    *time.Location // week boundaries and send time
+}
+
+// Subscriber is one enabled subscription ready to be sent.
+type Subscriber struct {
+	UserID int
+	Email  string
+	Filter Filter
+}
+
+// Store loads ranking changes and subscriptions and sends the digest
+// through the email outbox.
+type Store struct {
+	db  *sql.DB
+	cfg Config
+}
+
+func NewStore(db *sql.DB, cfg Config) *Store {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	return &Store{db: db, cfg: cfg}
+}
+
+// Location is the time zone weeks are counted in.
+func (s *Store) Location() *time.Location { return s.cfg.Location }
+
+// Sections returns the changes for every ranking published during the week
+// before weekStart, each compared with the last ranking published before
+// that week. Rankings with nothing to compare against are left out.
+func (s *Store) Sections(ctx context.Context, weekStart time.Time) ([]Section, error) {
+	from := weekStart.AddDate(0, 0, -7)
+
+	sections, err := s.snapshotSections(ctx, from, weekStart)
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := s.releaseSections(ctx, from, weekStart)
+	if err != nil {
+		return nil, err
+	}
+	sections = append(sections, legacy...)
+
+	out := sections[:0]
+	for _, sec := range sections {
+		if !sec.Empty() {
+			out = append(out, sec)
+		}
+	}
+	SortSections(out)
+	return out, nil
+}
+
+// snapshotSections diffs core.ranking_snapshot, one list per source, season
+// and weight class.
+func (s *Store) snapshotSections(ctx context.Context, from, to time.Time) ([]Section, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		WITH latest AS (
+			SELECT DISTINCT ON (rs.source_id, rs.season_id, rs.weight_class_id)
+				rs.id, rs.source_id, rs.season_id, rs.weight_class_id, rs.snapshot_date
+			FROM core.ranking_snapshot rs
+			WHERE rs.status = 'published'
+			  AND rs.snapshot_date >= $1::date AND rs.snapshot_date < $2::date
+			ORDER BY rs.source_id, rs.season_id, rs.weight_class_id, rs.snapshot_date DESC
+		)
+		SELECT l.id, prev.id, src.name, wc.label, l.snapshot_date::TEXT
+		FROM latest l
+		JOIN core.ranking_source src ON src.id = l.source_id
+		JOIN core.weight_class wc ON wc.id = l.weight_class_id
+		JOIN LATERAL (
+			SELECT p.id FROM core.ranking_snapshot p
+			WHERE p.source_id = l.source_id
+			  AND p.season_id = l.season_id
+			  AND p.weight_class_id = l.weight_class_id
+			  AND p.status = 'published'
+			  AND p.snapshot_date < $1::date
+			ORDER BY p.snapshot_date DESC
+			LIMIT 1
+		) prev ON true
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	type pair struct{ cur, prev, source, weight, date string }
+	var pairs []pair
+	for rows.Next() {
+		var p pair
+		if err := rows.Scan(&p.cur, &p.prev, &p.source, &p.weight, &p.date); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pairs = append(pairs, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var out []Section
+	for _, p := range pairs {
+		prev, err := s.snapshotEntries(ctx, p.prev)
+		if err != nil {
+			return nil, err
+		}
+		cur, err := s.snapshotEntries(ctx, p.cur)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Diff(
+			List{Source: p.source, WeightClass: p.weight, Entries: prev},
+			List{Source: p.source, WeightClass: p.weight, Date: p.date, Entries: cur},
+		))
+	}
+	return out, nil
+}
+
+func (s *Store) snapshotEntries(ctx context.Context, snapshotID string) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT re.rank, w.id, w.full_name, COALESCE(sc.name, ''), COALESCE(sc.slug, '')
+		FROM core.ranking_entry re
+		JOIN core.ranking_snapshot rs ON rs.id = re.snapshot_id
+		JOIN core.wrestler w ON w.id = re.wrestler_id
+		LEFT JOIN core.wrestler_season ws ON ws.wrestler_id = w.id AND ws.season_id = rs.season_id
+		LEFT JOIN core.school sc ON sc.id = ws.school_id
+		WHERE re.snapshot_id = $1
+		ORDER BY re.rank
+	`, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.Rank, &e.WrestlerID, &e.Name, &e.School, &e.SchoolSlug); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// releaseSections diffs the legacy rankings_releases published through the
+// admin UI. A release covers every weight class, so each produces one section
+// per weight.
+func (s *Store) releaseSections(ctx context.Context, from, to time.Time) ([]Section, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		WITH latest AS (
+			SELECT DISTINCT ON (r.source, r.season) r.id, r.source, r.season, r.week_of
+			FROM rankings_releases r
+			WHERE r.status = 'published' AND r.published_at >= $1 AND r.published_at < $2
+			ORDER BY r.source, r.season, r.week_of DESC
+		)
+		SELECT l.id, prev.id, l.source, l.week_of::TEXT
+		FROM latest l
+		JOIN LATERAL (
+			SELECT p.id FROM rankings_releases p
+			WHERE p.source = l.source AND p.season = l.season
+			  AND p.status = 'published' AND p.week_of < l.week_of
+			ORDER BY p.week_of DESC
+			LIMIT 1
+		) prev ON true
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	type pair struct {
+		cur, prev      int
+		source, weekOf string
+	}
+	var pairs []pair
+	for rows.Next() {
+		var p pair
+		if err := rows.Scan(&p.cur, &p.prev, &p.source, &p.weekOf); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pairs = append(pairs, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var out []Section
+	for _, p := range pairs {
+		prev, err := s.releaseEntries(ctx, p.prev)
+		if err != nil {
+			return nil, err
+		}
+		cur, err := s.releaseEntries(ctx, p.cur)
+		if err != nil {
+			return nil, err
+		}
+		for weight, entries := range cur {
+			out = append(out, Diff(
+				List{Source: p.source, WeightClass: weight, Entries: prev[weight]},
+				List{Source: p.source, WeightClass: weight, Date: p.weekOf, Entries: entries},
+			))
+		}
+	}
+	return out, nil
+}
+
+// releaseEntries groups a release's entries by weight class. Names and
+// schools come from the staging rows they were published from; wrestlers are
+// matched to core.wrestler by WrestleStat id where possible.
+func (s *Store) releaseEntries(ctx context.Context, releaseID int) (map[string][]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.weight_class, e.rank,
+		       COALESCE(w.id::TEXT, ''),
+		       COALESCE(w.full_name, st.name, ''),
+		       COALESCE(sc.name, st.school, ''),
+		       COALESCE(sc.slug, '')
+		FROM rankings_release_entries e
+		LEFT JOIN rankings_release_staging_rows st
+			ON st.release_id = e.release_id AND st.weight_class = e.weight_class AND st.rank = e.rank
+		LEFT JOIN core.wrestler w ON w.wrestlestat_id = e.wrestlestat_id::TEXT
+		LEFT JOIN LATERAL (
+			SELECT sch.name, sch.slug
+			FROM core.wrestler_season ws
+			JOIN core.season se ON se.id = ws.season_id
+			JOIN core.school sch ON sch.id = ws.school_id
+			WHERE ws.wrestler_id = w.id
+			ORDER BY se.year DESC
+			LIMIT 1
+		) sc ON true
+		WHERE e.release_id = $1
+		ORDER BY e.weight_class, e.rank
+	`, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string][]Entry{}
+	for rows.Next() {
+		var weight string
+		var e Entry
+		if err := rows.Scan(&weight, &e.Rank, &e.WrestlerID, &e.Name, &e.School, &e.SchoolSlug); err != nil {
+			return nil, err
+		}
+		out[weight] = append(out[weight], e)
+	}
+	return out, rows.Err()
+}
+
+const subscriberQuery = `
+	SELECT d.user_id, u.email, d.weight_classes,
+	       ARRAY(SELECT slug FROM core.school WHERE id = ANY(d.school_ids)),
+	       ARRAY(SELECT id::TEXT FROM unnest(d.wrestler_ids) AS id)
+	FROM digest_subscriptions d
+	JOIN users u ON u.id = d.user_id
+`
+
+func scanSubscriber(row interface{ Scan(...any) error }) (Subscriber, error) {
+	var sub Subscriber
+	err := row.Scan(&sub.UserID, &sub.Email,
+		pq.Array(&sub.Filter.WeightClasses),
+		pq.Array(&sub.Filter.SchoolSlugs),
+		pq.Array(&sub.Filter.WrestlerIDs),
+	)
+	return sub, err
+}
+
+// Subscriber loads one user's subscription. Users without one get a zero
+// filter, which is what a preview for them should show.
+func (s *Store) Subscriber(ctx context.Context, userID int) (Subscriber, error) {
+	sub, err := scanSubscriber(s.db.QueryRowContext(ctx, subscriberQuery+` WHERE d.user_id = $1`, userID))
+	if err == sql.ErrNoRows {
+		var email string
+		if err := s.db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&email); err != nil {
+			return Subscriber{}, err
+		}
+		return Subscriber{UserID: userID, Email: email}, nil
+	}
+	return sub, err
+}
+
+// dueSubscribers lists verified, enabled subscribers not yet sent weekStart.
+func (s *Store) dueSubscribers(ctx context.Context, weekStart time.Time) ([]Subscriber, error) {
+	rows, err := s.db.QueryContext(ctx, subscriberQuery+`
+		WHERE d.enabled AND u.verified
+		  AND (d.last_sent_week IS NULL OR d.last_sent_week < $1::date)
+		ORDER BY d.user_id
+	`, weekStart.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Subscriber
+	for rows.Next() {
+		sub, err := scanSubscriber(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
+
+// Email renders the digest for one subscriber. The body links to an
+// unsubscribe page; with an API URL configured the message also carries
+// RFC 8058 one-click unsubscribe headers.
+func (s *Store) Email(sub Subscriber, d Digest) (mail.Message, error) {
+	token := url.QueryEscape(UnsubscribeToken(s.cfg.Secret, sub.UserID))
+	msg, err := mail.Render("weekly_digest", map[string]any{
+		"Subject":        Subject(d.WeekOf),
+		"Sections":       d.Sections,
+		"ManageURL":      s.cfg.FrontendURL + "/account/notifications",
+		"UnsubscribeURL": s.cfg.FrontendURL + "/unsubscribe?token=" + token,
+	})
+	if err != nil {
+		return mail.Message{}, err
+	}
+	msg.To = []string{sub.Email}
+	if s.cfg.APIURL != "" {
+		msg.Headers = map[string]string{
+			"List-Unsubscribe":      "<" + s.cfg.APIURL + "/api/gable/digest/unsubscribe?token=" + token + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}
+	}
+	return msg, nil
+}
+
+// SendWeek queues the digest for weekStart to every due subscriber and
+// returns how many were queued. Each subscriber is marked in the same
+// transaction as their email, so concurrent or repeated runs send once.
+// Subscribers whose filter matches nothing are marked without an email.
+func (s *Store) SendWeek(ctx context.Context, weekStart time.Time) (int, error) {
+	sections, err := s.Sections(ctx, weekStart)
+	if err != nil {
+		return 0, fmt.Errorf("load ranking changes: %w", err)
+	}
+	subs, err := s.dueSubscribers(ctx, weekStart)
+	if err != nil {
+		return 0, fmt.Errorf("load subscribers: %w", err)
+	}
+
+	queued := 0
+	for _, sub := range subs {
+		sent, err := s.sendOne(ctx, sub, Digest{WeekOf: weekStart, Sections: sub.Filter.Apply(sections)})
+		if err != nil {
+			log.Printf("digest: user %d: %v", sub.UserID, err)
+			continue
+		}
+		if sent {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+func (s *Store) sendOne(ctx context.Context, sub Subscriber, d Digest) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE digest_subscriptions SET last_sent_week = $2::date
+		WHERE user_id = $1 AND enabled
+		  AND (last_sent_week IS NULL OR last_sent_week < $2::date)
+	`, sub.UserID, d.WeekOf.Format("2006-01-02"))
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil // another run got here first, or they unsubscribed
+	}
+
+	sent := len(d.Sections) > 0
+	if sent {
+		msg, err := s.Email(sub, d)
+		if err != nil {
+			return false, err
+		}
+		if err := mail.Enqueue(tx, "weekly_digest", msg); err != nil {
+			return false, err
+		}
+	}
+	return sent, tx.Commit()
+}
+
+// Run sends each week's digest once it is due, checking every few minutes
+// until ctx is cancelled.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	var lastWeek time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			week := WeekStart(now, s.cfg.Location)
+			if week.Equal(lastWeek) || !Due(now, week) {
+				continue
+			}
+			n, err := s.SendWeek(ctx, week)
+			if err != nil {
+				log.Printf("digest: week of %s: %v", week.Format("2006-01-02"), err)
+				continue
+			}
+			log.Printf("digest: week of %s: queued %d emails", week.Format("2006-01-02"), n)
+			lastWeek = week
+		}
+	}
+}
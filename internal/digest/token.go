@@ -0,0 +1,43 @@
+package digest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidToken is returned for malformed or forged unsubscribe tokens.
+var ErrInvalidToken = errors.New("invalid unsubscribe token")
+
+// UnsubscribeToken returns a token that unsubscribes userID without signing
+// in. It does not expire: old emails must keep working.
+func UnsubscribeToken(secret []byte, userID int) string {
+	id := strconv.Itoa(userID)
+	return id + "." + base64.RawURLEncoding.EncodeToString(tokenMAC(secret, id))
+}
+
+// ParseUnsubscribeToken verifies token and returns the user it was issued for.
+func ParseUnsubscribeToken(secret []byte, token string) (int, error) {
+	id, sig, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	userID, err := strconv.Atoi(id)
+	if err != nil || userID <= 0 {
+		return 0, ErrInvalidToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, tokenMAC(secret, id)) {
+		return 0, ErrInvalidToken
+	}
+	return userID, nil
+}
+
+func tokenMAC(secret []byte, id string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("digest-unsubscribe:" + id))
+	return mac.Sum(nil)
+}
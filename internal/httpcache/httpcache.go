@@ -0,0 +1,102 @@
+// Package httpcache supports HTTP caching of platform API responses.
+//
+// Triggers bump core.data_version in the same transaction as any write to
+// the core tables platform responses read from. Responses are tagged with
+// that version, so a client or CDN holding the current tag can be answered
+// with 304 Not Modified without running the handler's queries.
+package httpcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// versionTTL bounds how long a bumped version can go unnoticed by this
+// instance if Expire is not called.
+const versionTTL = 5 * time.Second
+
+// Policy is the Cache-Control sent with cacheable responses.
+type Policy struct {
+	MaxAge               time.Duration
+	StaleWhileRevalidate time.Duration
+}
+
+// Header renders p as a Cache-Control value. Responses are public: they vary
+// only by URL and API key, and callers send Vary: X-API-Key.
+func (p Policy) Header() string {
+	h := "public, max-age=" + strconv.Itoa(int(p.MaxAge.Seconds()))
+	if p.StaleWhileRevalidate > 0 {
+		h += ", stale-while-revalidate=" + strconv.Itoa(int(p.StaleWhileRevalidate.Seconds()))
+	}
+	return h
+}
+
+// Tag is the strong ETag for a response built from data version and
+// identified by parts (URL, caller scopes).
+func Tag(version int64, parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return fmt.Sprintf(`"v%d-%s"`, version, hex.EncodeToString(sum[:8]))
+}
+
+// Matches reports whether an If-None-Match header value matches etag. Per
+// RFC 9110 the comparison is weak, so W/ prefixes are ignored.
+func Matches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Versions reads core.data_version, re-checking it at most every versionTTL.
+type Versions struct {
+	db *sql.DB
+
+	mu      sync.Mutex
+	version int64
+	read    time.Time
+	stale   bool
+}
+
+func NewVersions(db *sql.DB) *Versions {
+	return &Versions{db: db}
+}
+
+// Current returns the data version. If it cannot be read, the last known
+// version is returned; it is an error only before the first read.
+func (v *Versions) Current(ctx context.Context) (int64, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.read.IsZero() && !v.stale && time.Since(v.read) < versionTTL {
+		return v.version, nil
+	}
+	var version int64
+	if err := v.db.QueryRowContext(ctx, `SELECT version FROM core.data_version`).Scan(&version); err != nil {
+		if v.read.IsZero() {
+			return 0, err
+		}
+		log.Printf("httpcache: read data version: %v", err)
+		return v.version, nil
+	}
+	v.version, v.read, v.stale = version, time.Now(), false
+	return version, nil
+}
+
+// Expire makes the next Current re-read the version, e.g. when a bump has
+// been announced.
+func (v *Versions) Expire() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.stale = true
+}
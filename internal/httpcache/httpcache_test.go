@@ -0,0 +1,53 @@
+package httpcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTag(t *testing.T) {
+	a := Tag(7, "/api/v1/rankings?season=2025", "*")
+	if a != Tag(7, "/api/v1/rankings?season=2025", "*") {
+		t.Fatal("Tag is not deterministic")
+	}
+	for _, other := range []string{
+		Tag(8, "/api/v1/rankings?season=2025", "*"),
+		Tag(7, "/api/v1/rankings?season=2024", "*"),
+		Tag(7, "/api/v1/rankings?season=2025", "rankings:read"),
+	} {
+		if other == a {
+			t.Errorf("Tag collision: %s", a)
+		}
+	}
+	if a[0] != '"' || a[len(a)-1] != '"' {
+		t.Errorf("Tag %s is not quoted", a)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tag := `"v7-abc"`
+	for header, want := range map[string]bool{
+		`"v7-abc"`:             true,
+		`W/"v7-abc"`:           true,
+		`"v6-abc", "v7-abc"`:   true,
+		`*`:                    true,
+		`"v6-abc"`:             false,
+		``:                     false,
+		`"v7-abc-extra"`:       false,
+		`"v6-abc",W/"v7-abc" `: true,
+	} {
+		if got := Matches(header, tag); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestPolicyHeader(t *testing.T) {
+	if got := (Policy{MaxAge: time.Minute}).Header(); got != "public, max-age=60" {
+		t.Errorf("Header = %q", got)
+	}
+	p := Policy{MaxAge: time.Hour, StaleWhileRevalidate: 24 * time.Hour}
+	if got := p.Header(); got != "public, max-age=3600, stale-while-revalidate=86400" {
+		t.Errorf("Header = %q", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"log"
 	"strings"
 )
 
@@ -27,10 +28,25 @@ type Repository interface {
 	InsertBout(ctx context.Context, tx Tx, input BoutInsertInput) (bool, error)
 }
 
-type Service struct{ repo Repository }
+// Refresher recomputes data derived from a committed batch's bouts;
+// stats.Store is the production implementation.
+type Refresher interface {
+	RefreshBatch(ctx context.Context, batchID string) error
+}
+
+type Service struct {
+	repo    Repository
+	refresh Refresher
+}
 
 func NewService(repo Repository) *Service { return &Service{repo: repo} }
 
+// WithRefresh runs r after each batch that inserted bouts commits.
+func (s *Service) WithRefresh(r Refresher) *Service {
+	s.refresh = r
+	return s
+}
+
 func (s *Service) Process(ctx context.Context, rows []CSVRecord) (ProcessResult, error) {
 	result := ProcessResult{RowsRead: len(rows)}
 	if len(rows) == 0 {
@@ -45,6 +61,7 @@ func (s *Service) Process(ctx context.Context, rows []CSVRecord) (ProcessResult,
 	if err != nil {
 		return result, fmt.Errorf("create ingest batch: %w", err)
 	}
+	result.BatchID = batchID
 	for i, row := range rows {
 		rowNum := i + 2
 		if !winnerMatches(row) {
@@ -65,6 +82,13 @@ func (s *Service) Process(ctx context.Context, rows []CSVRecord) (ProcessResult,
 	if err := tx.Commit(); err != nil {
 		return result, fmt.Errorf("commit tx: %w", err)
 	}
+	if s.refresh != nil && result.BoutsInserted > 0 {
+		// The bouts are committed either way; a failed refresh is repaired
+		// by the startup backfill, which recomputes stale seasons.
+		if err := s.refresh.RefreshBatch(ctx, batchID); err != nil {
+			log.Printf("trackdual: refresh batch %s: %v", batchID, err)
+		}
+	}
 	return result, nil
 }
 
@@ -84,3 +84,31 @@ func TestService_IdempotentBoutInsertAndValidationErrors(t *testing.T) {
 		t.Fatalf("expected duplicate on second run, got %+v", result2)
 	}
 }
+
+type fakeRefresher struct{ batches []string }
+
+func (f *fakeRefresher) RefreshBatch(_ context.Context, batchID string) error {
+	f.batches = append(f.batches, batchID)
+	return nil
+}
+
+func TestService_RefreshesAfterInsertingBouts(t *testing.T) {
+	row := CSVRecord{
+		SeasonYear: 2025, EventName: "Event", EventDate: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), EventType: "Dual", DualID: "D1",
+		WeightLabel: "149", BoutNumber: 3, WrestlerAName: "A Name", WrestlerASchoolSlug: "iowa", WrestlerBName: "B Name", WrestlerBSchoolSlug: "osu",
+		WinnerName: "A Name", ResultMethod: "FALL", MatchTime: "1:05", SourceMatchID: "M1",
+	}
+
+	refresher := &fakeRefresher{}
+	svc := NewService(newFakeRepo()).WithRefresh(refresher)
+	if _, err := svc.Process(context.Background(), []CSVRecord{row}); err != nil {
+		t.Fatalf("unexpected error process #1: %v", err)
+	}
+	if _, err := svc.Process(context.Background(), []CSVRecord{row}); err != nil {
+		t.Fatalf("unexpected error process #2: %v", err)
+	}
+	// The second batch only duplicated bouts, so there was nothing to refresh.
+	if len(refresher.batches) != 1 || refresher.batches[0] != "batch-1" {
+		t.Fatalf("refreshed batches = %v, want [batch-1]", refresher.batches)
+	}
+}
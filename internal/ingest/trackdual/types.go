@@ -50,6 +50,7 @@ type BoutInsertInput struct {
 }
 
 type ProcessResult struct {
+	BatchID         string `json:"-"` // core.ingest_batch id; empty when no rows were read
 	RowsRead        int
 	RowsSucceeded   int
 	RowsFailed      int
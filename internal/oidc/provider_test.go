@@ -0,0 +1,154 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIssuer is a minimal OIDC provider: discovery, JWKS, and a token
+// endpoint that checks the PKCE verifier before minting an ID token.
+type mockIssuer struct {
+	srv       *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+	slash     string // appended to the issuer, as some providers do
+}
+
+func (m *mockIssuer) issuer() string { return m.srv.URL + m.slash }
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	m := &mockIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.issuer(),
+			"authorization_endpoint": m.srv.URL + "/authorize",
+			"token_endpoint":         m.srv.URL + "/token",
+			"jwks_uri":               m.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if ChallengeS256(r.PostForm.Get("code_verifier")) != m.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            m.issuer(),
+			"aud":            "gable",
+			"sub":            "user-123",
+			"email":          "Fan@Example.com",
+			"email_verified": true,
+			"nonce":          m.nonce,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		tok.Header["kid"] = "k1"
+		signed, _ := tok.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func TestProvider_AuthorizationCodeWithPKCE(t *testing.T) {
+	m := newMockIssuer(t)
+	ctx := context.Background()
+
+	p, err := Discover(ctx, Config{
+		IssuerURL:   m.srv.URL + "/",
+		ClientID:    "gable",
+		RedirectURL: "http://localhost/callback",
+	}, nil)
+	if err != nil {
+		t.Fatalf("Discover returned error: %v", err)
+	}
+
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatalf("NewPKCE returned error: %v", err)
+	}
+	m.challenge = challenge
+	m.nonce = "nonce-1"
+
+	authURL, err := url.Parse(p.AuthCodeURL("state-1", "nonce-1", challenge))
+	if err != nil {
+		t.Fatalf("AuthCodeURL is not a valid URL: %v", err)
+	}
+	q := authURL.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") != challenge || q.Get("state") != "state-1" {
+		t.Fatalf("unexpected authorization query: %s", authURL.RawQuery)
+	}
+
+	tok, err := p.Exchange(ctx, "code-1", verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange returned error: %v", err)
+	}
+	if tok.Subject != "user-123" || tok.Email != "fan@example.com" || !tok.EmailVerified {
+		t.Fatalf("unexpected id token: %+v", tok)
+	}
+
+	if _, err := p.Exchange(ctx, "code-1", "wrong-verifier", "nonce-1"); err == nil {
+		t.Fatalf("expected error for a mismatched PKCE verifier")
+	}
+	if _, err := p.Exchange(ctx, "code-1", verifier, "other-nonce"); err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Fatalf("expected nonce mismatch error, got %v", err)
+	}
+}
+
+// Providers such as Auth0 publish an issuer ending in "/"; tokens must be
+// checked against it as published, while identities use the trimmed form.
+func TestProvider_IssuerWithTrailingSlash(t *testing.T) {
+	m := newMockIssuer(t)
+	m.slash = "/"
+	ctx := context.Background()
+
+	p, err := Discover(ctx, Config{IssuerURL: m.srv.URL + "/", ClientID: "gable"}, nil)
+	if err != nil {
+		t.Fatalf("Discover returned error: %v", err)
+	}
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatalf("NewPKCE returned error: %v", err)
+	}
+	m.challenge = challenge
+	m.nonce = "nonce-1"
+
+	tok, err := p.Exchange(ctx, "code-1", verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange returned error: %v", err)
+	}
+	if tok.Issuer != m.srv.URL || p.Issuer() != m.srv.URL {
+		t.Fatalf("identity issuer = %q / %q, want %q", tok.Issuer, p.Issuer(), m.srv.URL)
+	}
+}
@@ -0,0 +1,203 @@
+// Package pagination implements keyset (cursor) pagination for the platform
+// API. Each endpoint whitelists its sort fields in a Spec. Cursors are
+// opaque to clients: they carry the sort and the last row's key.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var (
+	ErrSort   = errors.New("unknown sort field")
+	ErrCursor = errors.New("invalid cursor")
+)
+
+// Column is one ORDER BY term. Expr must never be NULL; wrap nullable
+// columns in COALESCE.
+type Column struct {
+	Expr string
+	Desc bool
+}
+
+func Asc(expr string) Column  { return Column{Expr: expr} }
+func Desc(expr string) Column { return Column{Expr: expr, Desc: true} }
+
+// Field is a value accepted by sort=. A leading "-" reverses every column.
+type Field struct {
+	Name    string
+	Columns []Column
+}
+
+// Spec is one endpoint's sort whitelist.
+type Spec struct {
+	Fields  []Field
+	Default string   // used when sort= is empty, e.g. "-date"
+	Unique  []string // ascending tiebreak columns that make the order total
+}
+
+// Page is the envelope for every v1 collection. Total is only filled when
+// the caller asks for it.
+type Page[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+	Total      *int    `json:"total,omitempty"`
+}
+
+// Params is a parsed page request.
+type Params struct {
+	Sort  string
+	Limit int
+	Total bool
+	order []Column
+	after []string
+}
+
+type cursor struct {
+	Sort string   `json:"s"`
+	Key  []string `json:"k"`
+}
+
+// Parse reads the sort, cursor, limit and total query values. An out of
+// range limit falls back to DefaultLimit.
+func (s Spec) Parse(sort, after, limit, total string) (Params, error) {
+	p := Params{Limit: DefaultLimit, Total: total == "true" || total == "1"}
+	if n, err := strconv.Atoi(limit); err == nil && n >= 1 && n <= MaxLimit {
+		p.Limit = n
+	}
+
+	p.Sort = strings.TrimSpace(sort)
+	if p.Sort == "" {
+		p.Sort = s.Default
+	}
+	name, desc := strings.TrimPrefix(p.Sort, "-"), strings.HasPrefix(p.Sort, "-")
+	var field *Field
+	for i := range s.Fields {
+		if s.Fields[i].Name == name {
+			field = &s.Fields[i]
+		}
+	}
+	if field == nil {
+		return p, fmt.Errorf("%w: use one of %s", ErrSort, strings.Join(s.names(), ", "))
+	}
+	for _, c := range field.Columns {
+		p.order = append(p.order, Column{Expr: c.Expr, Desc: c.Desc != desc})
+	}
+	for _, u := range s.Unique {
+		p.order = append(p.order, Column{Expr: u, Desc: desc})
+	}
+
+	if after != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(after)
+		if err != nil {
+			return p, ErrCursor
+		}
+		var c cursor
+		if err := json.Unmarshal(raw, &c); err != nil || c.Sort != p.Sort || len(c.Key) != len(p.order) {
+			return p, ErrCursor
+		}
+		p.after = c.Key
+	}
+	return p, nil
+}
+
+func (s Spec) names() []string {
+	var out []string
+	for _, f := range s.Fields {
+		out = append(out, f.Name, "-"+f.Name)
+	}
+	return out
+}
+
+// First reports whether this is the first page (no cursor).
+func (p Params) First() bool {
+	return p.after == nil
+}
+
+// OrderBy is the ORDER BY list, without the keyword.
+func (p Params) OrderBy() string {
+	terms := make([]string, len(p.order))
+	for i, c := range p.order {
+		terms[i] = c.Expr + direction(c.Desc)
+	}
+	return strings.Join(terms, ", ")
+}
+
+// KeyColumns selects the cursor key; scan it into a []string per row and
+// pass the rows to Next.
+func (p Params) KeyColumns() string {
+	cols := make([]string, len(p.order))
+	for i, c := range p.order {
+		cols[i] = "(" + c.Expr + ")::TEXT"
+	}
+	return strings.Join(cols, ", ")
+}
+
+// KeyDest returns scan destinations for KeyColumns.
+func (p Params) KeyDest() ([]string, []interface{}) {
+	key := make([]string, len(p.order))
+	dest := make([]interface{}, len(key))
+	for i := range key {
+		dest[i] = &key[i]
+	}
+	return key, dest
+}
+
+// Keyset is the WHERE condition for rows after the cursor, or "" on the
+// first page. bind adds a query argument and returns its placeholder.
+func (p Params) Keyset(bind func(v interface{}) string) string {
+	if p.after == nil {
+		return ""
+	}
+	ph := make([]string, len(p.after))
+	for i, v := range p.after {
+		ph[i] = bind(v)
+	}
+	// (a > x) OR (a = x AND b > y) OR ..., so columns may mix directions.
+	var or []string
+	for i, c := range p.order {
+		var and []string
+		for j := 0; j < i; j++ {
+			and = append(and, p.order[j].Expr+" = "+ph[j])
+		}
+		op := " > "
+		if c.Desc {
+			op = " < "
+		}
+		and = append(and, c.Expr+op+ph[i])
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	return "(" + strings.Join(or, " OR ") + ")"
+}
+
+// FetchLimit is one more than Limit, to learn whether another page exists.
+func (p Params) FetchLimit() int {
+	return p.Limit + 1
+}
+
+// Next takes the keys of the fetched rows (at most FetchLimit) and returns
+// how many rows belong to this page and the cursor for the next, if any.
+func (p Params) Next(keys [][]string) (int, *string) {
+	if len(keys) <= p.Limit {
+		return len(keys), nil
+	}
+	raw, _ := json.Marshal(cursor{Sort: p.Sort, Key: keys[p.Limit-1]})
+	next := base64.RawURLEncoding.EncodeToString(raw)
+	return p.Limit, &next
+}
+
+func direction(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+	return " ASC"
+}
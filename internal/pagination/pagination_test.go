@@ -0,0 +1,98 @@
+package pagination
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+var spec = Spec{
+	Fields: []Field{
+		{Name: "name", Columns: []Column{Asc("w.full_name")}},
+		{Name: "date", Columns: []Column{Desc("ev.event_date"), Asc("wc.sort_order")}},
+	},
+	Default: "name",
+	Unique:  []string{"w.id"},
+}
+
+func TestParseSort(t *testing.T) {
+	p, err := spec.Parse("", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Sort != "name" || p.Limit != DefaultLimit || p.Total {
+		t.Fatalf("defaults = %+v", p)
+	}
+	if got := p.OrderBy(); got != "w.full_name ASC, w.id ASC" {
+		t.Errorf("OrderBy = %q", got)
+	}
+
+	p, _ = spec.Parse("-date", "", "10", "true")
+	if got := p.OrderBy(); got != "ev.event_date ASC, wc.sort_order DESC, w.id DESC" {
+		t.Errorf("reversed OrderBy = %q", got)
+	}
+	if p.Limit != 10 || !p.Total {
+		t.Errorf("limit/total = %d/%v", p.Limit, p.Total)
+	}
+
+	if _, err := spec.Parse("score", "", "", ""); !errors.Is(err, ErrSort) {
+		t.Errorf("unknown sort err = %v", err)
+	}
+	for _, limit := range []string{"0", "201", "x"} {
+		if p, _ := spec.Parse("", "", limit, ""); p.Limit != DefaultLimit {
+			t.Errorf("limit %q -> %d", limit, p.Limit)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	p, _ := spec.Parse("date", "", "2", "")
+	keys := [][]string{
+		{"2025-01-05", "3", "a"},
+		{"2025-01-05", "4", "b"},
+		{"2025-01-04", "1", "c"},
+	}
+	n, next := p.Next(keys)
+	if n != 2 || next == nil {
+		t.Fatalf("Next = %d, %v", n, next)
+	}
+	if n, next := p.Next(keys[:2]); n != 2 || next != nil {
+		t.Fatalf("last page Next = %d, %v", n, next)
+	}
+
+	p, err := spec.Parse("date", *next, "2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args []interface{}
+	got := p.Keyset(func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	})
+	want := "((ev.event_date < $1) OR (ev.event_date = $1 AND wc.sort_order > $2) OR " +
+		"(ev.event_date = $1 AND wc.sort_order = $2 AND w.id > $3))"
+	if got != want {
+		t.Errorf("Keyset =\n%s\nwant\n%s", got, want)
+	}
+	if len(args) != 3 || args[0] != "2025-01-05" || args[1] != "4" || args[2] != "b" {
+		t.Errorf("args = %v", args)
+	}
+
+	// A cursor only continues the sort it was issued for.
+	if _, err := spec.Parse("-date", *next, "", ""); err != ErrCursor {
+		t.Errorf("cursor with another sort err = %v", err)
+	}
+	if _, err := spec.Parse("date", "not-a-cursor", "", ""); err != ErrCursor {
+		t.Errorf("garbage cursor err = %v", err)
+	}
+}
+
+func TestKeysetFirstPage(t *testing.T) {
+	p, _ := spec.Parse("", "", "", "")
+	if got := p.Keyset(func(interface{}) string { return "$1" }); got != "" {
+		t.Errorf("first page Keyset = %q", got)
+	}
+	if !p.First() {
+		t.Error("First() = false without a cursor")
+	}
+}
@@ -0,0 +1,148 @@
+// Package profile validates the user-chosen parts of a public profile.
+package profile
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+const (
+	MinDisplayNameLen = 3
+	MaxDisplayNameLen = 20
+	MaxAvatarSeedLen  = 64
+)
+
+var (
+	ErrDisplayNameLength   = errors.New("display name must be 3 to 20 characters")
+	ErrDisplayNameChars    = errors.New("display name may only contain letters, numbers, '_', '-' and '.'")
+	ErrDisplayNameStart    = errors.New("display name must start with a letter or number")
+	ErrDisplayNameReserved = errors.New("display name is not allowed")
+	ErrAvatarSeed          = errors.New("avatar seed must be up to 64 letters, numbers, '_' or '-'")
+)
+
+// reservedExact are names that would be confusing as a whole but are fine
+// inside a longer name.
+var reservedExact = map[string]bool{
+	"me": true, "you": true, "api": true, "root": true, "null": true, "nil": true,
+	"undefined": true, "anonymous": true, "deleted": true, "unknown": true,
+	"user": true, "users": true, "guest": true, "help": true, "settings": true,
+}
+
+// reservedWords may not appear as a word of a name, or spread over several
+// (plurals included), after common digit substitutions are folded back to
+// letters. Words are split at separators, digits and camelCase humps, so
+// "real_ADM1N" and "GableGame" are caught while "Badminton" and
+// "Scunthorpe" are not. The first group prevents impersonating staff; the
+// rest is a basic profanity screen.
+var reservedWords = map[string]bool{
+	"admin": true, "moderator": true, "gablegame": true, "support": true,
+	"official": true, "staff": true, "system": true,
+	"shit": true, "cunt": true, "bitch": true, "nigga": true, "nigger": true,
+	"fag": true, "faggot": true, "whore": true, "slut": true, "rape": true, "nazi": true,
+}
+
+// reservedContains may not appear anywhere in a name, after separators are
+// removed and digits folded. Only words no innocent name contains belong
+// here.
+var reservedContains = []string{"fuck"}
+
+var leetFold = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "9", "g",
+	"_", "", "-", "", ".", "",
+)
+
+// nameWords splits a display name into lower-cased, digit-folded words.
+func nameWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			words = append(words, leetFold.Replace(strings.ToLower(string(runes[start:end]))))
+		}
+		start = end
+	}
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == '.' {
+			flush(i)
+			start = i + 1
+			continue
+		}
+		if i == start {
+			continue
+		}
+		prev := runes[i-1]
+		switch {
+		case unicode.IsDigit(prev) != unicode.IsDigit(r),
+			unicode.IsLower(prev) && unicode.IsUpper(r),
+			unicode.IsUpper(prev) && unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			flush(i)
+		}
+	}
+	flush(len(runes))
+	return words
+}
+
+func reservedWord(w string) bool {
+	return reservedWords[w] || reservedWords[strings.TrimSuffix(w, "s")] || reservedWords[strings.TrimSuffix(w, "es")]
+}
+
+// NormalizeDisplayName trims surrounding whitespace. Case is preserved for
+// display; uniqueness is enforced case-insensitively by the database.
+func NormalizeDisplayName(name string) string {
+	return strings.TrimSpace(name)
+}
+
+// ValidateDisplayName checks length, characters and the reserved-word filter.
+func ValidateDisplayName(name string) error {
+	n := len([]rune(name))
+	if n < MinDisplayNameLen || n > MaxDisplayNameLen {
+		return ErrDisplayNameLength
+	}
+	for i, r := range name {
+		alnum := r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+		if i == 0 && !alnum {
+			return ErrDisplayNameStart
+		}
+		if !alnum && r != '_' && r != '-' && r != '.' {
+			return ErrDisplayNameChars
+		}
+	}
+
+	lower := strings.ToLower(name)
+	if reservedExact[lower] {
+		return ErrDisplayNameReserved
+	}
+	words := nameWords(name)
+	for i := range words {
+		joined := ""
+		for _, w := range words[i:] {
+			joined += w
+			if reservedWord(joined) {
+				return ErrDisplayNameReserved
+			}
+		}
+	}
+	folded := leetFold.Replace(lower)
+	for _, w := range reservedContains {
+		if strings.Contains(folded, w) {
+			return ErrDisplayNameReserved
+		}
+	}
+	return nil
+}
+
+// ValidateAvatarSeed accepts an empty seed (the client derives one from the
+// user id) or a short token passed through to the avatar generator.
+func ValidateAvatarSeed(seed string) error {
+	if len(seed) > MaxAvatarSeedLen {
+		return ErrAvatarSeed
+	}
+	for _, r := range seed {
+		if !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) && r != '_' && r != '-' {
+			return ErrAvatarSeed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package profile
+
+import "testing"
+
+func TestValidateDisplayName(t *testing.T) {
+	cases := []struct {
+		name string
+		want error
+	}{
+		{"PinKing", nil},
+		{"cael_fan.99", nil},
+		{"ab", ErrDisplayNameLength},
+		{"abcdefghijklmnopqrstu", ErrDisplayNameLength},
+		{"_leading", ErrDisplayNameStart},
+		{"two words", ErrDisplayNameChars},
+		{"émile", ErrDisplayNameStart},
+		{"Admin", ErrDisplayNameReserved},
+		{"real_ADM1N", ErrDisplayNameReserved},
+		{"gable-game", ErrDisplayNameReserved},
+		{"null", ErrDisplayNameReserved},
+		{"nullset", nil},
+		{"adminBob", ErrDisplayNameReserved},
+		{"GableGame", ErrDisplayNameReserved},
+		{"b1tches", ErrDisplayNameReserved},
+		{"fuckface", ErrDisplayNameReserved},
+		{"Scunthorpe", nil},
+		{"badminton_fan", nil},
+		{"GrapeVine", nil},
+		{"Nazir", nil},
+		{"Systematic", nil},
+	}
+	for _, tc := range cases {
+		if got := ValidateDisplayName(tc.name); got != tc.want {
+			t.Errorf("ValidateDisplayName(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestValidateAvatarSeed(t *testing.T) {
+	if err := ValidateAvatarSeed(""); err != nil {
+		t.Fatalf("empty seed should be allowed: %v", err)
+	}
+	if err := ValidateAvatarSeed("wrestler-42"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ValidateAvatarSeed("<script>"); err != ErrAvatarSeed {
+		t.Fatalf("expected ErrAvatarSeed, got %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+// Package ratelimit enforces per-minute and per-day request quotas for the
+// platform API and records usage by route and status.
+//
+// Quota counters live in Postgres and are incremented with a single upsert
+// per request, so limits hold across any number of API instances. Usage
+// analytics are buffered in memory and flushed periodically as increments,
+// trading a few seconds of visibility for one write per flush instead of per
+// request.
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	flushInterval    = 10 * time.Second
+	cleanupInterval  = time.Hour
+	tierCacheTTL     = time.Minute
+	counterRetention = 48 * time.Hour
+	usageRetention   = 180 * 24 * time.Hour
+)
+
+// Limits are the quotas of one tier. Zero means unlimited.
+type Limits struct {
+	PerMinute int
+	PerDay    int
+}
+
+// Decision is the outcome of one counted request.
+type Decision struct {
+	Allowed    bool
+	Limit      int // per-minute limit, for X-RateLimit-* headers
+	Remaining  int
+	Reset      time.Time // end of the current minute window
+	RetryAfter time.Duration
+}
+
+// Windows returns the UTC start of the minute and day windows containing now.
+func Windows(now time.Time) (minute, day time.Time) {
+	now = now.UTC()
+	return now.Truncate(time.Minute), time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Decide applies l to the counts after this request was counted.
+func Decide(l Limits, minuteCount, dayCount int, now time.Time) Decision {
+	minute, day := Windows(now)
+	d := Decision{
+		Allowed: true,
+		Limit:   l.PerMinute,
+		Reset:   minute.Add(time.Minute),
+	}
+	if l.PerMinute > 0 {
+		d.Remaining = max(l.PerMinute-minuteCount, 0)
+	}
+
+	if l.PerDay > 0 && dayCount > l.PerDay {
+		d.Allowed = false
+		d.RetryAfter = day.Add(24 * time.Hour).Sub(now)
+	} else if l.PerMinute > 0 && minuteCount > l.PerMinute {
+		d.Allowed = false
+		d.RetryAfter = d.Reset.Sub(now)
+	}
+	return d
+}
+
+type usageKey struct {
+	Day     time.Time
+	Subject string
+	Route   string
+	Status  int
+}
+
+type Store struct {
+	db *sql.DB
+
+	tierMu    sync.Mutex
+	tiers     map[string]Limits
+	tiersRead time.Time
+
+	usageMu sync.Mutex
+	usage   map[usageKey]int
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db, usage: map[usageKey]int{}}
+}
+
+// Limits returns the quotas for tier, from a copy of api_tiers refreshed
+// every minute. Unknown tiers get the anonymous limits.
+func (s *Store) Limits(ctx context.Context, tier string) (Limits, error) {
+	s.tierMu.Lock()
+	defer s.tierMu.Unlock()
+
+	if s.tiers == nil || time.Since(s.tiersRead) > tierCacheTTL {
+		rows, err := s.db.QueryContext(ctx, `SELECT name, per_minute, per_day FROM api_tiers`)
+		if err != nil {
+			if s.tiers == nil {
+				return Limits{}, err
+			}
+			log.Printf("ratelimit: refresh tiers: %v", err)
+		} else {
+			tiers := map[string]Limits{}
+			for rows.Next() {
+				var name string
+				var l Limits
+				if err := rows.Scan(&name, &l.PerMinute, &l.PerDay); err != nil {
+					rows.Close()
+					return Limits{}, err
+				}
+				tiers[name] = l
+			}
+			rows.Close()
+			s.tiers = tiers
+			s.tiersRead = time.Now()
+		}
+	}
+
+	if l, ok := s.tiers[tier]; ok {
+		return l, nil
+	}
+	return s.tiers["anonymous"], nil
+}
+
+// Hit counts one request for subject and returns the updated minute and day counts.
+func (s *Store) Hit(ctx context.Context, subject string, now time.Time) (minuteCount, dayCount int, err error) {
+	minute, day := Windows(now)
+	rows, err := s.db.QueryContext(ctx, `
+		INSERT INTO api_rate_counters (subject, window_kind, window_start, count)
+		VALUES ($1, 'minute', $2, 1), ($1, 'day', $3, 1)
+		ON CONFLICT (subject, window_kind, window_start)
+		DO UPDATE SET count = api_rate_counters.count + 1
+		RETURNING window_kind, count`,
+		subject, minute, day,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var n int
+		if err := rows.Scan(&kind, &n); err != nil {
+			return 0, 0, err
+		}
+		if kind == "minute" {
+			minuteCount = n
+		} else {
+			dayCount = n
+		}
+	}
+	return minuteCount, dayCount, rows.Err()
+}
+
+// RecordUsage buffers one request for the usage tables.
+func (s *Store) RecordUsage(subject, route string, status int, now time.Time) {
+	_, day := Windows(now)
+	s.usageMu.Lock()
+	s.usage[usageKey{Day: day, Subject: subject, Route: route, Status: status}]++
+	s.usageMu.Unlock()
+}
+
+// Flush writes buffered usage as increments. On failure the counts are put
+// back so the next flush retries them.
+func (s *Store) Flush(ctx context.Context) error {
+	s.usageMu.Lock()
+	pending := s.usage
+	s.usage = map[usageKey]int{}
+	s.usageMu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := s.writeUsage(ctx, pending)
+	if err != nil {
+		s.usageMu.Lock()
+		for k, n := range pending {
+			s.usage[k] += n
+		}
+		s.usageMu.Unlock()
+	}
+	return err
+}
+
+func (s *Store) writeUsage(ctx context.Context, pending map[usageKey]int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO api_usage_daily (day, subject, route, status, count)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (day, subject, route, status)
+		DO UPDATE SET count = api_usage_daily.count + EXCLUDED.count`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, n := range pending {
+		if _, err := stmt.ExecContext(ctx, k.Day, k.Subject, k.Route, k.Status, n); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Cleanup deletes expired quota windows and old usage rows.
+func (s *Store) Cleanup(ctx context.Context, now time.Time) error {
+	if _, err := s.db.ExecContext(ctx,
+		`DELETE FROM api_rate_counters WHERE window_start < $1`, now.Add(-counterRetention),
+	); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM api_usage_daily WHERE day < $1`, now.Add(-usageRetention),
+	)
+	return err
+}
+
+// Run flushes usage and cleans up old rows until ctx is cancelled.
+func (s *Store) Run(ctx context.Context) {
+	flush := time.NewTicker(flushInterval)
+	cleanup := time.NewTicker(cleanupInterval)
+	defer flush.Stop()
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Flush(context.Background()); err != nil {
+				log.Printf("ratelimit: final usage flush: %v", err)
+			}
+			return
+		case <-flush.C:
+			if err := s.Flush(ctx); err != nil {
+				log.Printf("ratelimit: usage flush: %v", err)
+			}
+		case now := <-cleanup.C:
+			if err := s.Cleanup(ctx, now); err != nil {
+				log.Printf("ratelimit: cleanup: %v", err)
+			}
+		}
+	}
+}
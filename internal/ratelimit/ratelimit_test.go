@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecide(t *testing.T) {
+	l := Limits{PerMinute: 10, PerDay: 100}
+	now := time.Date(2026, 3, 1, 15, 4, 20, 0, time.UTC)
+
+	d := Decide(l, 3, 50, now)
+	if !d.Allowed || d.Remaining != 7 || d.Limit != 10 {
+		t.Fatalf("unexpected decision under limit: %+v", d)
+	}
+	if want := time.Date(2026, 3, 1, 15, 5, 0, 0, time.UTC); !d.Reset.Equal(want) {
+		t.Fatalf("reset = %v, want %v", d.Reset, want)
+	}
+
+	d = Decide(l, 10, 50, now)
+	if !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("the limit-th request should be allowed: %+v", d)
+	}
+
+	d = Decide(l, 11, 50, now)
+	if d.Allowed || d.RetryAfter != 40*time.Second {
+		t.Fatalf("expected minute rejection with 40s retry, got %+v", d)
+	}
+
+	// The daily quota wins and points at UTC midnight.
+	d = Decide(l, 11, 101, now)
+	if d.Allowed || d.RetryAfter != 8*time.Hour+55*time.Minute+40*time.Second {
+		t.Fatalf("expected daily rejection until midnight, got %+v", d)
+	}
+
+	if d := Decide(Limits{}, 1000, 100000, now); !d.Allowed {
+		t.Fatalf("zero limits should be unlimited: %+v", d)
+	}
+}
+
+func TestWindows_UTC(t *testing.T) {
+	est := time.FixedZone("EST", -5*3600)
+	minute, day := Windows(time.Date(2026, 3, 1, 22, 30, 15, 0, est))
+	if !minute.Equal(time.Date(2026, 3, 2, 3, 30, 0, 0, time.UTC)) {
+		t.Fatalf("minute window = %v", minute)
+	}
+	if !day.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("day window = %v", day)
+	}
+}
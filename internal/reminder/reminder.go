@@ -0,0 +1,77 @@
+// Package reminder decides when a player's daily reminder email is due.
+// Puzzle days follow the game clock in PuzzleTimeZone; reminder times are
+// the player's own local time.
+package reminder
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PuzzleTimeZone is the clock the daily puzzle rolls over on.
+const PuzzleTimeZone = "America/New_York"
+
+// Grace is how late a reminder may still go out, e.g. after a restart. Past
+// it the day is skipped rather than nagging at an odd hour.
+const Grace = 2 * time.Hour
+
+var ErrClock = errors.New("reminder time must be HH:MM (24-hour)")
+
+// Clock is a time of day in minutes after midnight.
+type Clock int
+
+// ParseClock parses "HH:MM" or the "HH:MM:SS" form Postgres returns for TIME.
+func ParseClock(s string) (Clock, error) {
+	var h, m, sec int
+	if n, _ := fmt.Sscanf(s, "%d:%d:%d", &h, &m, &sec); n < 2 {
+		return 0, ErrClock
+	}
+	if len(s) != 5 && len(s) != 8 {
+		return 0, ErrClock
+	}
+	if h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, ErrClock
+	}
+	return Clock(h*60 + m), nil
+}
+
+func (c Clock) String() string { return fmt.Sprintf("%02d:%02d", int(c)/60, int(c)%60) }
+
+// PuzzleDay returns the puzzle date (YYYY-MM-DD) in effect at t.
+func PuzzleDay(t time.Time, puzzleLoc *time.Location) string {
+	return t.In(puzzleLoc).Format("2006-01-02")
+}
+
+// Due reports whether a reminder set for at in the player's zone should be
+// sent at now, and for which puzzle day. It is not due before the local
+// reminder time, after Grace, if the puzzle rolled over since the reminder
+// time, or if one was already sent for that puzzle day.
+func Due(now time.Time, userLoc, puzzleLoc *time.Location, at Clock, lastSentDay string) (string, bool) {
+	local := now.In(userLoc)
+	sched := time.Date(local.Year(), local.Month(), local.Day(), int(at)/60, int(at)%60, 0, 0, userLoc)
+	if now.Before(sched) || now.Sub(sched) > Grace {
+		return "", false
+	}
+	day := PuzzleDay(now, puzzleLoc)
+	if PuzzleDay(sched, puzzleLoc) != day || lastSentDay == day {
+		return "", false
+	}
+	return day, true
+}
+
+// StreakAtRisk is the streak a player keeps by playing on day. The stored
+// current_streak only counts if the last win was the previous puzzle day.
+func StreakAtRisk(currentStreak int, lastWin *time.Time, day string) int {
+	if lastWin == nil || currentStreak <= 0 {
+		return 0
+	}
+	d, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return 0
+	}
+	if lastWin.Format("2006-01-02") != d.AddDate(0, 0, -1).Format("2006-01-02") {
+		return 0
+	}
+	return currentStreak
+}
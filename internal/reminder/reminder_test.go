@@ -0,0 +1,82 @@
+package reminder
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestParseClock(t *testing.T) {
+	for in, want := range map[string]Clock{"00:00": 0, "18:30": 18*60 + 30, "23:59:00": 23*60 + 59} {
+		if got, err := ParseClock(in); err != nil || got != want {
+			t.Errorf("ParseClock(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "7:30", "24:00", "12:60", "noon", "12:30pm"} {
+		if _, err := ParseClock(in); err != ErrClock {
+			t.Errorf("ParseClock(%q) err = %v, want ErrClock", in, err)
+		}
+	}
+	if s := Clock(9*60 + 5).String(); s != "09:05" {
+		t.Errorf("String = %q", s)
+	}
+}
+
+func TestDue(t *testing.T) {
+	ny := mustLoad(t, PuzzleTimeZone)
+	la := mustLoad(t, "America/Los_Angeles")
+	at := Clock(18 * 60)
+
+	before := time.Date(2026, 10, 18, 17, 59, 0, 0, la)
+	if _, ok := Due(before, la, ny, at, ""); ok {
+		t.Fatalf("due before the reminder time")
+	}
+
+	// 18:00 in LA is 21:00 in New York, still the 18th's puzzle.
+	onTime := time.Date(2026, 10, 18, 18, 5, 0, 0, la)
+	day, ok := Due(onTime, la, ny, at, "2026-10-17")
+	if !ok || day != "2026-10-18" {
+		t.Fatalf("Due = %q, %v; want 2026-10-18, true", day, ok)
+	}
+	if _, ok := Due(onTime, la, ny, at, "2026-10-18"); ok {
+		t.Fatalf("due again after sending for the same puzzle day")
+	}
+	if _, ok := Due(onTime.Add(Grace+time.Minute), la, ny, at, ""); ok {
+		t.Fatalf("due after the grace period")
+	}
+
+	// 22:30 in LA is 01:30 in New York: the puzzle it would nag about has
+	// already rolled over, so it goes out for the new day.
+	late := Clock(22*60 + 30)
+	day, ok = Due(time.Date(2026, 10, 18, 22, 31, 0, 0, la), la, ny, late, "")
+	if !ok || day != "2026-10-19" {
+		t.Fatalf("late reminder: Due = %q, %v", day, ok)
+	}
+
+	// Already reminded for the 19th by the late reminder above.
+	if _, ok := Due(time.Date(2026, 10, 19, 0, 30, 0, 0, la), la, ny, Clock(0), "2026-10-19"); ok {
+		t.Fatalf("due twice for one puzzle day")
+	}
+}
+
+func TestStreakAtRisk(t *testing.T) {
+	yesterday := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	older := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	if got := StreakAtRisk(6, &yesterday, "2026-10-18"); got != 6 {
+		t.Errorf("streak from yesterday = %d, want 6", got)
+	}
+	if got := StreakAtRisk(6, &older, "2026-10-18"); got != 0 {
+		t.Errorf("stale streak = %d, want 0", got)
+	}
+	if got := StreakAtRisk(3, nil, "2026-10-18"); got != 0 {
+		t.Errorf("no wins = %d, want 0", got)
+	}
+}
@@ -0,0 +1,178 @@
+package reminder
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"gable-backend/mail"
+)
+
+const checkInterval = 2 * time.Minute
+
+// Store finds players due a reminder and queues it through the email outbox.
+type Store struct {
+	db          *sql.DB
+	frontendURL string
+	puzzleLoc   *time.Location
+	zones       map[string]*time.Location
+}
+
+func NewStore(db *sql.DB, frontendURL string) *Store {
+	loc, err := time.LoadLocation(PuzzleTimeZone)
+	if err != nil {
+		log.Printf("reminder: load %s: %v; using UTC", PuzzleTimeZone, err)
+		loc = time.UTC
+	}
+	return &Store{
+		db:          db,
+		frontendURL: strings.TrimRight(frontendURL, "/"),
+		puzzleLoc:   loc,
+		zones:       map[string]*time.Location{},
+	}
+}
+
+type candidate struct {
+	userID     int
+	email      string
+	at         Clock
+	zone       string
+	lastSent   string
+	streak     int
+	lastWinDay sql.NullTime
+}
+
+// SendDue queues reminders for everyone due at now who has not played the
+// current puzzle, returning how many were queued.
+func (s *Store) SendDue(ctx context.Context, now time.Time) (int, error) {
+	today := PuzzleDay(now, s.puzzleLoc)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.user_id, u.email, p.reminder_time::TEXT, p.time_zone,
+		       COALESCE(p.last_reminder_date::TEXT, ''),
+		       COALESCE(st.current_streak, 0), st.last_win_date
+		FROM notification_preferences p
+		JOIN users u ON u.id = p.user_id AND u.verified
+		LEFT JOIN user_stats st ON st.user_id = p.user_id
+		WHERE p.daily_reminder
+		  AND (p.last_reminder_date IS NULL OR p.last_reminder_date < $1::date)
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_guesses g WHERE g.user_id = p.user_id AND g.guess_date = $1::date
+		  )
+	`, today)
+	if err != nil {
+		return 0, err
+	}
+	var due []candidate
+	for rows.Next() {
+		var cand candidate
+		var at string
+		if err := rows.Scan(&cand.userID, &cand.email, &at, &cand.zone, &cand.lastSent, &cand.streak, &cand.lastWinDay); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		clock, err := ParseClock(at)
+		if err != nil {
+			continue
+		}
+		cand.at = clock
+		due = append(due, cand)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, cand := range due {
+		userLoc := s.zone(cand.zone)
+		day, ok := Due(now, userLoc, s.puzzleLoc, cand.at, cand.lastSent)
+		if !ok || day != today {
+			continue
+		}
+		var lastWin *time.Time
+		if cand.lastWinDay.Valid {
+			lastWin = &cand.lastWinDay.Time
+		}
+		sent, err := s.sendOne(ctx, cand, day, StreakAtRisk(cand.streak, lastWin, day))
+		if err != nil {
+			log.Printf("reminder: user %d: %v", cand.userID, err)
+			continue
+		}
+		if sent {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// zone resolves an IANA zone name, falling back to the puzzle clock for
+// names that no longer load.
+func (s *Store) zone(name string) *time.Location {
+	if loc, ok := s.zones[name]; ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = s.puzzleLoc
+	}
+	s.zones[name] = loc
+	return loc
+}
+
+// sendOne marks the puzzle day as reminded and queues the email in one
+// transaction, re-checking that the player still has not played.
+func (s *Store) sendOne(ctx context.Context, cand candidate, day string, streak int) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE notification_preferences SET last_reminder_date = $2::date
+		WHERE user_id = $1 AND daily_reminder
+		  AND (last_reminder_date IS NULL OR last_reminder_date < $2::date)
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_guesses g WHERE g.user_id = $1 AND g.guess_date = $2::date
+		  )
+	`, cand.userID, day)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	msg, err := mail.DailyReminder(cand.email, streak, s.frontendURL, s.frontendURL+"/account/notifications")
+	if err != nil {
+		return false, err
+	}
+	if err := mail.Enqueue(tx, "daily_reminder", msg); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Run checks for due reminders every couple of minutes until ctx is cancelled.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := s.SendDue(ctx, now)
+			if err != nil {
+				log.Printf("reminder: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("reminder: queued %d emails", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package results normalizes bout outcomes from ingestion sources and scores
+// dual meets from them.
+package results
+
+import (
+	"sort"
+	"strings"
+)
+
+// Canonical result methods served by the platform API.
+const (
+	Decision         = "DEC"
+	MajorDecision    = "MD"
+	TechFall         = "TF"
+	Fall             = "FALL"
+	Forfeit          = "FOR"
+	InjuryDefault    = "INJ"
+	Disqualification = "DQ"
+	NoContest        = "NC"
+)
+
+// Methods lists the canonical methods in the order clients should show them.
+var Methods = []string{Decision, MajorDecision, TechFall, Fall, Forfeit, InjuryDefault, Disqualification, NoContest}
+
+// aliases maps a squashed source spelling (see Squash) to its canonical method.
+// Overtime variants (SV-1, TB-2, UTB) are decisions.
+var aliases = map[string]string{
+	"DEC": Decision, "DECISION": Decision, "D": Decision,
+	"SV": Decision, "TB": Decision, "UTB": Decision,
+	"MD": MajorDecision, "MAJ": MajorDecision, "MAJORDECISION": MajorDecision,
+	"TF": TechFall, "TECH": TechFall, "TECHFALL": TechFall,
+	"FALL": Fall, "F": Fall, "PIN": Fall,
+	"FOR": Forfeit, "FF": Forfeit, "FORFEIT": Forfeit, "MFF": Forfeit, "MFOR": Forfeit,
+	"INJ": InjuryDefault, "DEF": InjuryDefault, "DEFAULT": InjuryDefault, "INJURY": InjuryDefault,
+	"DQ": Disqualification, "DISQ": Disqualification, "DISQUALIFICATION": Disqualification,
+	"NC": NoContest,
+}
+
+// SquashSQL is the SQL counterpart of Squash, with %s standing for the column.
+const SquashSQL = `upper(regexp_replace(COALESCE(%s, ''), '[^A-Za-z]', '', 'g'))`
+
+// Squash keeps only ASCII letters, upper-cased, so "SV-1", "sv 1" and "SV"
+// compare equal.
+func Squash(raw string) string {
+	var b strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+		}
+	}
+	return b.String()
+}
+
+// Normalize returns the canonical method for a source spelling, or "" when
+// it is not recognised.
+func Normalize(raw string) string {
+	return aliases[Squash(raw)]
+}
+
+// ParseMethod accepts a canonical method or any known alias from a client.
+func ParseMethod(s string) (string, bool) {
+	m := Normalize(s)
+	return m, m != ""
+}
+
+// Aliases returns every squashed spelling that normalizes to method, sorted,
+// for matching stored rows against SquashSQL.
+func Aliases(method string) []string {
+	var out []string
+	for alias, m := range aliases {
+		if m == method {
+			out = append(out, alias)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// TeamPoints is what a win by method scores in a college dual.
+func TeamPoints(method string) int {
+	switch method {
+	case Decision:
+		return 3
+	case MajorDecision:
+		return 4
+	case TechFall:
+		return 5
+	case Fall, Forfeit, InjuryDefault, Disqualification:
+		return 6
+	}
+	return 0
+}
+
+// DualBout is one bout of a dual meet. Teams are identified by any stable
+// key, such as the school id.
+type DualBout struct {
+	TeamA, TeamB string
+	AWon         bool
+	Method       string // canonical
+}
+
+// TeamScore is one side's total in a dual meet.
+type TeamScore struct {
+	Team     string
+	Points   int
+	BoutsWon int
+}
+
+// ScoreDual totals team points per team, highest first. Bouts with an
+// unknown team are skipped.
+func ScoreDual(bouts []DualBout) []TeamScore {
+	byTeam := map[string]*TeamScore{}
+	var order []string
+	team := func(key string) *TeamScore {
+		t, ok := byTeam[key]
+		if !ok {
+			t = &TeamScore{Team: key}
+			byTeam[key] = t
+			order = append(order, key)
+		}
+		return t
+	}
+	for _, b := range bouts {
+		if b.TeamA == "" || b.TeamB == "" {
+			continue
+		}
+		a, o := team(b.TeamA), team(b.TeamB)
+		winner := o
+		if b.AWon {
+			winner = a
+		}
+		winner.Points += TeamPoints(b.Method)
+		winner.BoutsWon++
+	}
+	out := make([]TeamScore, 0, len(order))
+	for _, key := range order {
+		out = append(out, *byTeam[key])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Points > out[j].Points })
+	return out
+}
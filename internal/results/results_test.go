@@ -0,0 +1,59 @@
+package results
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"Dec":     Decision,
+		"SV-1":    Decision,
+		"tb 2":    Decision,
+		"M.D.":    MajorDecision,
+		"TF 18-3": TechFall,
+		"Fall":    Fall,
+		"F":       Fall,
+		"M FOR":   Forfeit,
+		"Inj.":    InjuryDefault,
+		"DQ":      Disqualification,
+		"":        "",
+		"bye":     "",
+	}
+	for raw, want := range cases {
+		if got := Normalize(raw); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestAliasesRoundTrip(t *testing.T) {
+	for _, m := range Methods {
+		aliases := Aliases(m)
+		if len(aliases) == 0 {
+			t.Fatalf("no aliases for %s", m)
+		}
+		for _, a := range aliases {
+			if Normalize(a) != m {
+				t.Errorf("alias %q of %s normalizes to %q", a, m, Normalize(a))
+			}
+		}
+	}
+}
+
+func TestScoreDual(t *testing.T) {
+	got := ScoreDual([]DualBout{
+		{TeamA: "iowa", TeamB: "osu", AWon: true, Method: Decision},
+		{TeamA: "iowa", TeamB: "osu", AWon: false, Method: Fall},
+		{TeamA: "osu", TeamB: "iowa", AWon: true, Method: TechFall},
+		{TeamA: "iowa", TeamB: "osu", AWon: true, Method: MajorDecision},
+		{TeamA: "", TeamB: "osu", AWon: true, Method: Fall},
+	})
+	want := []TeamScore{
+		{Team: "osu", Points: 11, BoutsWon: 2},
+		{Team: "iowa", Points: 7, BoutsWon: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ScoreDual = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+// Package search parses platform search requests. Matching and ranking
+// happen in Postgres (see migration 026_search.sql).
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Entity types a search can return.
+const (
+	Wrestler   = "wrestler"
+	School     = "school"
+	Conference = "conference"
+	Event      = "event"
+)
+
+// Types lists every searchable type.
+var Types = []string{Wrestler, School, Conference, Event}
+
+// MinQueryLen is the shortest query, in runes, worth matching.
+const MinQueryLen = 2
+
+var ErrUnknownType = errors.New("unknown search type")
+
+// ParseTypes reads a comma-separated type filter. An empty filter means
+// every type. The result keeps the order of Types, without duplicates.
+func ParseTypes(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return append([]string(nil), Types...), nil
+	}
+	want := map[string]bool{}
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		known := false
+		for _, k := range Types {
+			known = known || k == t
+		}
+		if !known {
+			return nil, ErrUnknownType
+		}
+		want[t] = true
+	}
+	var out []string
+	for _, t := range Types {
+		if want[t] {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// PrefixQuery turns free text into a tsquery that matches every word as a
+// prefix, e.g. "spencer le" -> "spencer:* & le:*". Punctuation is dropped,
+// so the result is always safe to pass to to_tsquery. It returns "" when q
+// has no words.
+func PrefixQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
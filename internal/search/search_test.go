@@ -0,0 +1,35 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTypes(t *testing.T) {
+	all, err := ParseTypes("")
+	if err != nil || !reflect.DeepEqual(all, Types) {
+		t.Fatalf("ParseTypes(\"\") = %v, %v", all, err)
+	}
+	got, err := ParseTypes(" Event,wrestler,event ")
+	if err != nil || !reflect.DeepEqual(got, []string{Wrestler, Event}) {
+		t.Fatalf("ParseTypes = %v, %v", got, err)
+	}
+	if _, err := ParseTypes("wrestler,team"); err != ErrUnknownType {
+		t.Fatalf("unknown type err = %v", err)
+	}
+}
+
+func TestPrefixQuery(t *testing.T) {
+	cases := map[string]string{
+		"Spencer Lee":        "spencer:* & lee:*",
+		"  O'Toole  ":        "o:* & toole:*",
+		"José & García | !x": "josé:* & garcía:* & x:*",
+		"":                   "",
+		"&|!():*":            "",
+	}
+	for in, want := range cases {
+		if got := PrefixQuery(in); got != want {
+			t.Errorf("PrefixQuery(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
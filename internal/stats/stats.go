@@ -0,0 +1,154 @@
+// Package stats derives per-season wrestler stats from bouts. Lines are
+// computed in Go, where result methods are normalized, and stored in
+// core.wrestler_season_stats.
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gable-backend/internal/results"
+)
+
+// Bout is one bout from a wrestler's side.
+type Bout struct {
+	Result         string // "W", "L", or "" when no winner is recorded
+	Method         string // canonical, see results.Methods
+	Score          *int
+	OpponentScore  *int
+	MatchTime      string
+	OpponentRanked bool // opponent held a published rank on the bout date
+}
+
+// Record is a win/loss count.
+type Record struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+}
+
+// Line is a wrestler's stat line for one season.
+type Line struct {
+	Record             Record            `json:"record"`
+	ByMethod           map[string]Record `json:"by_method"`
+	BonusPct           *float64          `json:"bonus_pct"`  // share of wins worth bonus team points
+	AvgMargin          *float64          `json:"avg_margin"` // own minus opponent score, over scored bouts
+	FastestFallSeconds *int              `json:"fastest_fall_seconds"`
+	FastestFall        *string           `json:"fastest_fall"` // M:SS
+	VsRanked           Record            `json:"vs_ranked"`
+}
+
+// Compute builds a Line from one season of bouts.
+func Compute(bouts []Bout) Line {
+	line := Line{ByMethod: map[string]Record{}}
+	for _, m := range results.Methods {
+		line.ByMethod[m] = Record{}
+	}
+
+	var bonus, margins, scored int
+	for _, b := range bouts {
+		switch b.Result {
+		case "W":
+			line.Record.Wins++
+			if results.TeamPoints(b.Method) > 3 {
+				bonus++
+			}
+			if b.Method == results.Fall {
+				if secs, ok := ParseMatchTime(b.MatchTime); ok && (line.FastestFallSeconds == nil || secs < *line.FastestFallSeconds) {
+					line.FastestFallSeconds = &secs
+				}
+			}
+		case "L":
+			line.Record.Losses++
+		default:
+			continue
+		}
+
+		if b.Method != "" {
+			r := line.ByMethod[b.Method]
+			addResult(&r, b.Result)
+			line.ByMethod[b.Method] = r
+		}
+		if b.OpponentRanked {
+			addResult(&line.VsRanked, b.Result)
+		}
+		if b.Score != nil && b.OpponentScore != nil {
+			margins += *b.Score - *b.OpponentScore
+			scored++
+		}
+	}
+
+	if line.Record.Wins > 0 {
+		pct := round(float64(bonus) / float64(line.Record.Wins))
+		line.BonusPct = &pct
+	}
+	if scored > 0 {
+		avg := round(float64(margins) / float64(scored))
+		line.AvgMargin = &avg
+	}
+	if line.FastestFallSeconds != nil {
+		s := FormatMatchTime(*line.FastestFallSeconds)
+		line.FastestFall = &s
+	}
+	return line
+}
+
+func addResult(r *Record, result string) {
+	if result == "W" {
+		r.Wins++
+	} else {
+		r.Losses++
+	}
+}
+
+// round keeps three decimal places so stored stats compare cleanly.
+func round(f float64) float64 {
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(f, 'f', 3, 64), 64)
+	return v
+}
+
+// regulation holds the start and length, in seconds, of each regulation
+// period of a college bout (3:00, 2:00, 2:00).
+var regulation = map[string][2]int{"P1": {0, 180}, "P2": {180, 120}, "P3": {300, 120}}
+
+// ParseMatchTime reads a time of bout such as "1:05", or "P2 0:48" (time
+// into the second period), as seconds since the bout started. Times in
+// overtime periods are rejected, since their start depends on how the
+// earlier periods went.
+func ParseMatchTime(s string) (int, bool) {
+	fields := strings.Fields(s)
+	var start, length int
+	switch len(fields) {
+	case 1:
+	case 2:
+		p, ok := regulation[strings.ToUpper(fields[0])]
+		if !ok {
+			return 0, false
+		}
+		start, length = p[0], p[1]
+	default:
+		return 0, false
+	}
+	mm, ss, ok := strings.Cut(fields[len(fields)-1], ":")
+	if !ok {
+		return 0, false
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || m < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(ss)
+	if err != nil || len(ss) != 2 || n < 0 || n > 59 {
+		return 0, false
+	}
+	secs := m*60 + n
+	if length > 0 && secs > length {
+		return 0, false
+	}
+	return start + secs, true
+}
+
+// FormatMatchTime formats seconds since the bout started as M:SS.
+func FormatMatchTime(secs int) string {
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
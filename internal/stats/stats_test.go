@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"testing"
+
+	"gable-backend/internal/results"
+)
+
+func intp(v int) *int { return &v }
+
+func TestCompute(t *testing.T) {
+	line := Compute([]Bout{
+		{Result: "W", Method: results.Fall, MatchTime: "2:10"},
+		{Result: "W", Method: results.Fall, MatchTime: "P1 0:48", OpponentRanked: true},
+		{Result: "W", Method: results.Decision, Score: intp(5), OpponentScore: intp(2)},
+		{Result: "W", Method: results.MajorDecision, Score: intp(12), OpponentScore: intp(3)},
+		{Result: "L", Method: results.Decision, Score: intp(1), OpponentScore: intp(3), OpponentRanked: true},
+		{Result: "", Method: results.NoContest},
+	})
+
+	if line.Record != (Record{Wins: 4, Losses: 1}) {
+		t.Fatalf("record = %+v", line.Record)
+	}
+	if got := line.ByMethod[results.Decision]; got != (Record{Wins: 1, Losses: 1}) {
+		t.Errorf("DEC = %+v", got)
+	}
+	if got := line.ByMethod[results.TechFall]; got != (Record{}) {
+		t.Errorf("TF = %+v, want zero entry", got)
+	}
+	if line.BonusPct == nil || *line.BonusPct != 0.75 {
+		t.Errorf("bonus_pct = %v, want 0.75", line.BonusPct)
+	}
+	// (3 + 9 - 2) / 3
+	if line.AvgMargin == nil || *line.AvgMargin != 3.333 {
+		t.Errorf("avg_margin = %v, want 3.333", line.AvgMargin)
+	}
+	if line.FastestFall == nil || *line.FastestFall != "0:48" || *line.FastestFallSeconds != 48 {
+		t.Errorf("fastest fall = %v / %v", line.FastestFall, line.FastestFallSeconds)
+	}
+	if line.VsRanked != (Record{Wins: 1, Losses: 1}) {
+		t.Errorf("vs_ranked = %+v", line.VsRanked)
+	}
+}
+
+func TestComputeEmpty(t *testing.T) {
+	line := Compute(nil)
+	if line.BonusPct != nil || line.AvgMargin != nil || line.FastestFall != nil {
+		t.Fatalf("empty line has derived stats: %+v", line)
+	}
+}
+
+func TestParseMatchTime(t *testing.T) {
+	for in, want := range map[string]int{
+		"1:05": 65, "0:48": 48, "10:59": 659,
+		"P1 1:05": 65, "P2 0:48": 228, "p3 2:00": 420,
+	} {
+		if got, ok := ParseMatchTime(in); !ok || got != want {
+			t.Errorf("ParseMatchTime(%q) = %d, %v; want %d", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{
+		"", "7", "1:5", "1:60", "a:00", "-1:00",
+		"P2 3:00", "SV-1 0:30", "TB1 0:15", "P2 extra 0:48",
+	} {
+		if _, ok := ParseMatchTime(in); ok {
+			t.Errorf("ParseMatchTime(%q) accepted", in)
+		}
+	}
+}
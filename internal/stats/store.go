@@ -0,0 +1,180 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"gable-backend/internal/results"
+)
+
+// Store refreshes core.wrestler_season_stats.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// RefreshBatch recomputes every season an ingest batch wrote bouts to.
+func (s *Store) RefreshBatch(ctx context.Context, batchID string) error {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT DISTINCT season_id FROM core.bout WHERE ingest_batch_id = $1`, batchID)
+	if err != nil {
+		return err
+	}
+	seasons, err := scanIDs(rows)
+	if err != nil {
+		return err
+	}
+	return s.refreshSeasons(ctx, seasons)
+}
+
+// Backfill computes seasons that have bouts but no stats yet, e.g. bouts
+// ingested before stats existed, and seasons with bouts from a batch that
+// completed after their stats were computed, e.g. when a refresh failed.
+func (s *Store) Backfill(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT season_id FROM (
+			SELECT b.season_id, max(ib.completed_at) AS newest,
+			       (SELECT min(st.computed_at) FROM core.wrestler_season_stats st
+			        WHERE st.season_id = b.season_id) AS computed
+			FROM core.bout b
+			LEFT JOIN core.ingest_batch ib ON ib.id = b.ingest_batch_id
+			GROUP BY b.season_id
+		) s
+		WHERE computed IS NULL OR newest > computed
+	`)
+	if err != nil {
+		return err
+	}
+	seasons, err := scanIDs(rows)
+	if err != nil {
+		return err
+	}
+	return s.refreshSeasons(ctx, seasons)
+}
+
+func (s *Store) refreshSeasons(ctx context.Context, seasons []string) error {
+	for _, id := range seasons {
+		if err := s.RefreshSeason(ctx, id); err != nil {
+			return fmt.Errorf("season %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// RefreshSeason replaces the stored lines for one season. Concurrent
+// refreshes of the same season are serialized.
+func (s *Store) RefreshSeason(ctx context.Context, seasonID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`SELECT pg_advisory_xact_lock(hashtext('wrestler_season_stats:' || $1))`, seasonID); err != nil {
+		return err
+	}
+
+	// Each bout appears once from each wrestler's side. An opponent counts
+	// as ranked when they appear in the latest published snapshot, on or
+	// before the event, of any source's rankings at any weight; a wrestler
+	// who has dropped out of the rankings since no longer counts.
+	rows, err := tx.QueryContext(ctx, `
+		WITH sides AS (
+			SELECT b.season_id, b.event_id, b.winner_id, b.result_method, b.match_time,
+			       b.wrestler_a_id AS wrestler_id, b.wrestler_b_id AS opponent_id,
+			       b.score_a AS score, b.score_b AS opponent_score
+			FROM core.bout b WHERE b.season_id = $1
+			UNION ALL
+			SELECT b.season_id, b.event_id, b.winner_id, b.result_method, b.match_time,
+			       b.wrestler_b_id, b.wrestler_a_id, b.score_b, b.score_a
+			FROM core.bout b WHERE b.season_id = $1
+		)
+		SELECT s.wrestler_id,
+		       CASE WHEN s.winner_id IS NULL THEN ''
+		            WHEN s.winner_id = s.wrestler_id THEN 'W' ELSE 'L' END,
+		       COALESCE(s.result_method, ''), s.score, s.opponent_score, COALESCE(s.match_time, ''),
+		       orank.ranked IS NOT NULL
+		FROM sides s
+		LEFT JOIN core.event ev ON ev.id = s.event_id
+		LEFT JOIN LATERAL (
+			SELECT true AS ranked
+			FROM core.ranking_entry re
+			JOIN core.ranking_snapshot rs ON rs.id = re.snapshot_id
+			WHERE re.wrestler_id = s.opponent_id
+			  AND rs.status = 'published'
+			  AND rs.season_id = s.season_id
+			  AND (ev.event_date IS NULL OR rs.snapshot_date <= ev.event_date)
+			  AND rs.snapshot_date = (
+			      SELECT max(latest.snapshot_date) FROM core.ranking_snapshot latest
+			      WHERE latest.source_id = rs.source_id
+			        AND latest.weight_class_id = rs.weight_class_id
+			        AND latest.status = 'published'
+			        AND latest.season_id = s.season_id
+			        AND (ev.event_date IS NULL OR latest.snapshot_date <= ev.event_date)
+			  )
+			LIMIT 1
+		) orank ON true
+	`, seasonID)
+	if err != nil {
+		return err
+	}
+	bouts := map[string][]Bout{}
+	for rows.Next() {
+		var (
+			wrestlerID, method string
+			b                  Bout
+		)
+		if err := rows.Scan(&wrestlerID, &b.Result, &method, &b.Score, &b.OpponentScore, &b.MatchTime, &b.OpponentRanked); err != nil {
+			rows.Close()
+			return err
+		}
+		b.Method = results.Normalize(method)
+		bouts[wrestlerID] = append(bouts[wrestlerID], b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM core.wrestler_season_stats WHERE season_id = $1`, seasonID); err != nil {
+		return err
+	}
+	for wrestlerID, wb := range bouts {
+		line, err := json.Marshal(Compute(wb))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO core.wrestler_season_stats (wrestler_id, season_id, stats)
+			VALUES ($1, $2, $3)
+		`, wrestlerID, seasonID, line); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Printf("stats: refreshed season %s (%d wrestlers)", seasonID, len(bouts))
+	return nil
+}
+
+func scanIDs(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
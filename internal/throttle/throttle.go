@@ -0,0 +1,204 @@
+// Package throttle implements failure counting with progressive lockout,
+// persisted in Postgres so every API instance sees the same counters.
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// lockoutMemory is how long an idle key remembers earlier lockouts. After this
+// much quiet time the next lockout starts again at BaseLockout.
+const lockoutMemory = 24 * time.Hour
+
+// Policy describes one throttled scope, e.g. failed logins per IP.
+type Policy struct {
+	Scope       string        // stored with each row, e.g. "login:ip"
+	MaxFailures int           // failures allowed within Window before locking
+	Window      time.Duration // sliding start: the count resets once the window has passed
+	BaseLockout time.Duration // first lockout; each further lockout doubles it
+	MaxLockout  time.Duration
+}
+
+// State is the persisted counter for one (scope, key) pair.
+type State struct {
+	Failures      int
+	WindowStarted time.Time
+	LastFailure   time.Time
+	Lockouts      int
+	LockedUntil   time.Time
+}
+
+// Entry is a State with its identity, as listed for admins.
+type Entry struct {
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+	State
+}
+
+// LockoutDuration returns the length of the n-th consecutive lockout (n >= 1).
+func (p Policy) LockoutDuration(n int) time.Duration {
+	d := p.BaseLockout
+	for i := 1; i < n; i++ {
+		d *= 2
+		if d >= p.MaxLockout {
+			return p.MaxLockout
+		}
+	}
+	if d > p.MaxLockout {
+		return p.MaxLockout
+	}
+	return d
+}
+
+// RecordFailure applies one failure at time now and returns the lockout it
+// triggered, or 0 if the key is still under its limit.
+func (p Policy) RecordFailure(st *State, now time.Time) time.Duration {
+	if !st.LastFailure.IsZero() && now.Sub(st.LastFailure) > lockoutMemory {
+		st.Lockouts = 0
+	}
+	if st.WindowStarted.IsZero() || now.Sub(st.WindowStarted) > p.Window {
+		st.Failures = 0
+		st.WindowStarted = now
+	}
+
+	st.Failures++
+	st.LastFailure = now
+
+	if st.Failures < p.MaxFailures {
+		return 0
+	}
+
+	st.Lockouts++
+	lock := p.LockoutDuration(st.Lockouts)
+	st.LockedUntil = now.Add(lock)
+	st.Failures = 0
+	st.WindowStarted = now
+	return lock
+}
+
+type Store struct{ db *sql.DB }
+
+func NewStore(db *sql.DB) *Store { return &Store{db: db} }
+
+// Check returns how much longer key is locked out under p, or 0.
+func (s *Store) Check(ctx context.Context, p Policy, key string) (time.Duration, error) {
+	var lockedUntil sql.NullTime
+	err := s.db.QueryRowContext(ctx,
+		`SELECT locked_until FROM auth_throttle WHERE scope = $1 AND key = $2`,
+		p.Scope, key,
+	).Scan(&lockedUntil)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !lockedUntil.Valid {
+		return 0, nil
+	}
+	if wait := time.Until(lockedUntil.Time); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// Fail records a failed (or, for attempt-limited scopes, any) attempt and
+// returns the lockout it triggered. The row is locked for the read-modify-write
+// so concurrent instances cannot lose increments.
+func (s *Store) Fail(ctx context.Context, p Policy, key string) (time.Duration, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO auth_throttle (scope, key) VALUES ($1, $2) ON CONFLICT (scope, key) DO NOTHING`,
+		p.Scope, key,
+	); err != nil {
+		return 0, err
+	}
+
+	var st State
+	var windowStarted, lastFailure, lockedUntil sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		SELECT failures, window_started_at, last_failure_at, lockouts, locked_until
+		FROM auth_throttle
+		WHERE scope = $1 AND key = $2
+		FOR UPDATE`,
+		p.Scope, key,
+	).Scan(&st.Failures, &windowStarted, &lastFailure, &st.Lockouts, &lockedUntil)
+	if err != nil {
+		return 0, err
+	}
+	st.WindowStarted = windowStarted.Time
+	st.LastFailure = lastFailure.Time
+	st.LockedUntil = lockedUntil.Time
+
+	lock := p.RecordFailure(&st, time.Now())
+
+	var until any
+	if !st.LockedUntil.IsZero() {
+		until = st.LockedUntil
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE auth_throttle
+		SET failures = $3, window_started_at = $4, last_failure_at = $5, lockouts = $6, locked_until = $7
+		WHERE scope = $1 AND key = $2`,
+		p.Scope, key, st.Failures, st.WindowStarted, st.LastFailure, st.Lockouts, until,
+	); err != nil {
+		return 0, err
+	}
+
+	return lock, tx.Commit()
+}
+
+// Reset forgets key's failures after a successful attempt.
+func (s *Store) Reset(ctx context.Context, p Policy, key string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM auth_throttle WHERE scope = $1 AND key = $2`, p.Scope, key,
+	)
+	return err
+}
+
+// Unlock clears a lockout by scope name; it is the admin override of Reset.
+func (s *Store) Unlock(ctx context.Context, scope, key string) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM auth_throttle WHERE scope = $1 AND key = $2`, scope, key,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListLocked returns keys that are currently locked out, longest lock first.
+func (s *Store) ListLocked(ctx context.Context) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT scope, key, failures, window_started_at, last_failure_at, lockouts, locked_until
+		FROM auth_throttle
+		WHERE locked_until > now()
+		ORDER BY locked_until DESC
+		LIMIT 500`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		var e Entry
+		var windowStarted, lastFailure, lockedUntil sql.NullTime
+		if err := rows.Scan(&e.Scope, &e.Key, &e.Failures, &windowStarted, &lastFailure, &e.Lockouts, &lockedUntil); err != nil {
+			return nil, err
+		}
+		e.WindowStarted = windowStarted.Time
+		e.LastFailure = lastFailure.Time
+		e.LockedUntil = lockedUntil.Time
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,61 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicy_ProgressiveLockout(t *testing.T) {
+	p := Policy{
+		Scope:       "login:account",
+		MaxFailures: 3,
+		Window:      15 * time.Minute,
+		BaseLockout: time.Minute,
+		MaxLockout:  5 * time.Minute,
+	}
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	var st State
+
+	// Two failures stay under the limit; the third locks for BaseLockout.
+	for i := 0; i < 2; i++ {
+		if lock := p.RecordFailure(&st, now); lock != 0 {
+			t.Fatalf("failure %d: unexpected lockout %v", i+1, lock)
+		}
+	}
+	if lock := p.RecordFailure(&st, now); lock != time.Minute {
+		t.Fatalf("expected 1m lockout, got %v", lock)
+	}
+
+	// Each further lockout doubles, capped at MaxLockout.
+	want := []time.Duration{2 * time.Minute, 4 * time.Minute, 5 * time.Minute}
+	for _, w := range want {
+		var lock time.Duration
+		for i := 0; i < 3; i++ {
+			now = now.Add(time.Second)
+			lock = p.RecordFailure(&st, now)
+		}
+		if lock != w {
+			t.Fatalf("expected %v lockout, got %v", w, lock)
+		}
+	}
+
+	// A day of quiet resets the progression.
+	now = now.Add(25 * time.Hour)
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Second)
+		if lock := p.RecordFailure(&st, now); i == 2 && lock != time.Minute {
+			t.Fatalf("expected progression reset to 1m, got %v", lock)
+		}
+	}
+}
+
+func TestPolicy_WindowExpiry(t *testing.T) {
+	p := Policy{MaxFailures: 2, Window: time.Minute, BaseLockout: time.Minute, MaxLockout: time.Hour}
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	var st State
+
+	p.RecordFailure(&st, now)
+	if lock := p.RecordFailure(&st, now.Add(2*time.Minute)); lock != 0 {
+		t.Fatalf("failure outside the window should start a new count, got lockout %v", lock)
+	}
+}
@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileMailer writes each message as an .eml file under Dir, for local
+// development. Open them with any mail client to check rendering.
+type FileMailer struct {
+	Dir string
+
+	mu  sync.Mutex
+	seq int
+}
+
+func (f *FileMailer) Send(ctx context.Context, msg Message) error {
+	msg, err := msg.withDefaults("no-reply@localhost", defaultFromName)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	body, err := buildMIME(msg, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.seq++
+	seq := f.seq
+	f.mu.Unlock()
+
+	to := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To[0])
+	name := fmt.Sprintf("%s-%03d-%s.eml", now.Format("20060102-150405"), seq, to)
+	return os.WriteFile(filepath.Join(f.Dir, name), body, 0o644)
+}
+
+// MemoryMailer keeps sent messages in memory, for tests.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	msg, err := msg.withDefaults("no-reply@localhost", defaultFromName)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.messages = append(m.messages, msg)
+	m.mu.Unlock()
+	return nil
+}
+
+// Messages returns a copy of everything sent so far.
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
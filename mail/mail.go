@@ -1,44 +1,80 @@
 package mail
 
-import (
-	"fmt"
-	"os"
-
-	"github.com/sendgrid/sendgrid-go"
-	"github.com/sendgrid/sendgrid-go/helpers/mail"
-)
-
-func SendVerificationEmail(to, verificationURL string) error {
-	fromEmail := os.Getenv("EMAIL_FROM") // e.g., no-reply@gablegame.com
-	apiKey := os.Getenv("SENDGRID_API_KEY")
-
-	subject := "Verify Your Email"
-	plainTextContent := fmt.Sprintf("Click the link to verify your email: %s", verificationURL)
-	htmlContent := fmt.Sprintf(`
-        <html>
-        <body>
-            <h2>Email Verification</h2>
-            <p>Thank you for registering! Please verify your email by clicking the link below:</p>
-            <p><a href="%s">Verify Email</a></p>
-            <p>If you didn't create this account, you can safely ignore this email.</p>
-        </body>
-        </html>
-    `, verificationURL)
-
-	from := mail.NewEmail("Gable Game", fromEmail)
-	toEmail := mail.NewEmail("", to)
-	message := mail.NewSingleEmail(from, subject, toEmail, plainTextContent, htmlContent)
-
-	client := sendgrid.NewSendClient(apiKey)
-	response, err := client.Send(message)
+// VerificationEmail asks a new user to confirm their address.
+func VerificationEmail(to, verificationURL string) (Message, error) {
+	return renderTo("verify_email", []string{to}, map[string]string{"URL": verificationURL})
+}
+
+// EmailChangeConfirmation asks the new address to confirm an email change.
+func EmailChangeConfirmation(to, confirmURL string) (Message, error) {
+	return renderTo("email_change_confirm", []string{to}, map[string]string{"URL": confirmURL})
+}
+
+// EmailChangedNotice tells the previous address that the account email changed.
+func EmailChangedNotice(to, newEmail string) (Message, error) {
+	return renderTo("email_changed_notice", []string{to}, map[string]string{"NewEmail": newEmail})
+}
+
+// ContactNotification forwards a contact form submission. Replies go to the sender.
+func ContactNotification(to []string, name, email, message string) (Message, error) {
+	msg, err := renderTo("contact_notification", to, map[string]string{
+		"Name":    name,
+		"Email":   email,
+		"Message": message,
+	})
+	msg.FromName = "Gable Game Contact"
+	msg.ReplyTo = email
+	return msg, err
+}
+
+// ContactReply answers a contact form submission, quoting the original message.
+func ContactReply(to, name, reply, original string) (Message, error) {
+	return renderTo("contact_reply", []string{to}, map[string]string{
+		"Name":     name,
+		"Reply":    reply,
+		"Original": original,
+	})
+}
 
+// DailyReminder nudges a player who has not played today's puzzle. A zero
+// streak gets the "start a new streak" wording.
+func DailyReminder(to string, streak int, playURL, manageURL string) (Message, error) {
+	return renderTo("daily_reminder", []string{to}, map[string]any{
+		"Streak":    streak,
+		"PlayURL":   playURL,
+		"ManageURL": manageURL,
+	})
+}
+
+// EnqueueVerificationEmail queues a verification email in the caller's transaction.
+func EnqueueVerificationEmail(db Execer, to, verificationURL string) error {
+	msg, err := VerificationEmail(to, verificationURL)
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		return err
 	}
+	return Enqueue(db, "verify_email", msg)
+}
 
-	if response.StatusCode >= 400 {
-		return fmt.Errorf("sendgrid error: %d - %s", response.StatusCode, response.Body)
+// EnqueueEmailChangeConfirmation queues the confirmation link for an email change.
+func EnqueueEmailChangeConfirmation(db Execer, to, confirmURL string) error {
+	msg, err := EmailChangeConfirmation(to, confirmURL)
+	if err != nil {
+		return err
 	}
+	return Enqueue(db, "email_change_confirm", msg)
+}
+
+// EnqueueEmailChangedNotice queues the notice sent to the previous address.
+func EnqueueEmailChangedNotice(db Execer, to, newEmail string) error {
+	msg, err := EmailChangedNotice(to, newEmail)
+	if err != nil {
+		return err
+	}
+	return Enqueue(db, "email_changed_notice", msg)
+}
 
-	return nil
+func renderTo(name string, to []string, data any) (Message, error) {
+	msg, err := Render(name, data)
+	msg.To = to
+	return msg, err
 }
@@ -0,0 +1,134 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContactNotification_EscapesHTML(t *testing.T) {
+	msg, err := ContactNotification([]string{"inbox@example.com"}, `<b>Dan</b>`, "fan@example.com", `<script>alert(1)</script>`)
+	if err != nil {
+		t.Fatalf("ContactNotification returned error: %v", err)
+	}
+	if strings.Contains(msg.HTML, "<script>") || strings.Contains(msg.HTML, "<b>Dan</b>") {
+		t.Fatalf("user input was not escaped in HTML body:\n%s", msg.HTML)
+	}
+	if !strings.Contains(msg.HTML, "&lt;script&gt;") {
+		t.Fatalf("expected escaped script tag in HTML body:\n%s", msg.HTML)
+	}
+	// The plain-text body carries the input verbatim.
+	if !strings.Contains(msg.Text, "<script>alert(1)</script>") {
+		t.Fatalf("unexpected text body:\n%s", msg.Text)
+	}
+	if msg.Subject != "[Gable Game] New Contact Message" || msg.ReplyTo != "fan@example.com" {
+		t.Fatalf("unexpected headers: subject=%q reply_to=%q", msg.Subject, msg.ReplyTo)
+	}
+}
+
+func TestRender_EachTemplateHasItsOwnSubject(t *testing.T) {
+	verify, err := VerificationEmail("a@example.com", "https://gable.test/verify?token=x&email=a")
+	if err != nil {
+		t.Fatalf("VerificationEmail returned error: %v", err)
+	}
+	notice, err := EmailChangedNotice("a@example.com", "b@example.com")
+	if err != nil {
+		t.Fatalf("EmailChangedNotice returned error: %v", err)
+	}
+	if verify.Subject != "Verify Your Email" || notice.Subject != "Your Email Was Changed" {
+		t.Fatalf("unexpected subjects: %q, %q", verify.Subject, notice.Subject)
+	}
+	if !strings.Contains(verify.HTML, `href="https://gable.test/verify?token=x&amp;email=a"`) {
+		t.Fatalf("verification link missing from HTML body:\n%s", verify.HTML)
+	}
+	if _, err := Render("no_such_email", nil); err == nil {
+		t.Fatalf("expected error for unknown template")
+	}
+}
+
+func TestMemoryAndFileMailers(t *testing.T) {
+	msg, _ := VerificationEmail("fan@example.com", "https://gable.test/verify")
+	ctx := context.Background()
+
+	mem := &MemoryMailer{}
+	if err := mem.Send(ctx, msg); err != nil {
+		t.Fatalf("MemoryMailer.Send returned error: %v", err)
+	}
+	if got := mem.Messages(); len(got) != 1 || got[0].To[0] != "fan@example.com" || got[0].FromName != defaultFromName {
+		t.Fatalf("unexpected captured messages: %+v", got)
+	}
+	if err := mem.Send(ctx, Message{Subject: "no one"}); err != ErrNoRecipients {
+		t.Fatalf("expected ErrNoRecipients, got %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := (&FileMailer{Dir: dir}).Send(ctx, msg); err != nil {
+		t.Fatalf("FileMailer.Send returned error: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected one .eml file, got %v", files)
+	}
+	raw, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(raw), "Subject: Verify Your Email") || !strings.Contains(string(raw), "multipart/alternative") {
+		t.Fatalf("unexpected .eml contents:\n%s", raw)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{10, 4*time.Hour + 16*time.Minute},
+		{11, 6 * time.Hour},
+		{50, 6 * time.Hour},
+	}
+	for _, tc := range cases {
+		if got := Backoff(tc.attempts); got != tc.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestCheckRequeue(t *testing.T) {
+	stored, err := json.Marshal(Message{To: []string{"a@example.com"}, Subject: "Verify Your Email", Text: "link", HTML: "<a>link</a>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckRequeue("dead", stored); err != nil {
+		t.Fatalf("dead message with its body: %v", err)
+	}
+	if err := CheckRequeue("sent", stored); err != ErrNotDead {
+		t.Fatalf("sent message err = %v, want ErrNotDead", err)
+	}
+	scrubbed := []byte(`{"to":["a@example.com"],"subject":"Verify Your Email"}`)
+	if err := CheckRequeue("dead", scrubbed); err != ErrBodyDiscarded {
+		t.Fatalf("scrubbed message err = %v, want ErrBodyDiscarded", err)
+	}
+}
+
+func TestDailyReminder(t *testing.T) {
+	msg, err := DailyReminder("fan@example.com", 6, "https://gable.example", "https://gable.example/account/notifications")
+	if err != nil {
+		t.Fatalf("DailyReminder returned error: %v", err)
+	}
+	if msg.Subject != "Keep your 6-day streak alive" || !strings.Contains(msg.Text, "6-day streak") {
+		t.Fatalf("unexpected streak email: %q\n%s", msg.Subject, msg.Text)
+	}
+
+	msg, err = DailyReminder("fan@example.com", 0, "https://gable.example", "https://gable.example/account/notifications")
+	if err != nil {
+		t.Fatalf("DailyReminder returned error: %v", err)
+	}
+	if msg.Subject != "Today's Gable Game puzzle is waiting" || strings.Contains(msg.Text, "day streak") {
+		t.Fatalf("unexpected no-streak email: %q\n%s", msg.Subject, msg.Text)
+	}
+}
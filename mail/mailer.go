@@ -0,0 +1,140 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Message is one outgoing email. From and FromName default to the mailer's
+// configured sender when empty.
+type Message struct {
+	To       []string `json:"to"`
+	From     string   `json:"from,omitempty"`
+	FromName string   `json:"from_name,omitempty"`
+	ReplyTo  string   `json:"reply_to,omitempty"`
+	Subject  string   `json:"subject"`
+	Text     string   `json:"text"`
+	HTML     string   `json:"html"`
+
+	// Headers are extra message headers, e.g. List-Unsubscribe.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Mailer delivers messages. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var ErrNoRecipients = errors.New("mail: message has no recipients")
+
+const (
+	defaultFromName = "Gable Game"
+	defaultMailDir  = "tmp/mail"
+)
+
+var (
+	defaultMu     sync.RWMutex
+	defaultMailer Mailer
+)
+
+// SetDefault installs the mailer used by the package-level Send helpers.
+func SetDefault(m Mailer) {
+	defaultMu.Lock()
+	defaultMailer = m
+	defaultMu.Unlock()
+}
+
+// Default returns the installed mailer, configuring one from the
+// environment on first use.
+func Default() Mailer {
+	defaultMu.RLock()
+	m := defaultMailer
+	defaultMu.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultMailer == nil {
+		defaultMailer = FromEnv()
+	}
+	return defaultMailer
+}
+
+// Send delivers msg through the default mailer.
+func Send(ctx context.Context, msg Message) error {
+	return Default().Send(ctx, msg)
+}
+
+// FromEnv picks a backend from MAIL_BACKEND (sendgrid, smtp or file). When
+// unset, SendGrid is used if SENDGRID_API_KEY is present and the file
+// backend otherwise, so local development never sends real email. On Render
+// (RENDER set) falling back to files exits instead: the outbox would mark
+// every message sent while nobody receives it.
+//
+//	EMAIL_FROM                                  sender address for every backend
+//	SENDGRID_API_KEY                            sendgrid
+//	SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD   smtp
+//	MAIL_DIR (default tmp/mail)                 file
+func FromEnv() Mailer {
+	from := os.Getenv("EMAIL_FROM") // e.g., no-reply@gablegame.com
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_BACKEND")))
+	if backend == "" {
+		switch {
+		case os.Getenv("SENDGRID_API_KEY") != "":
+			backend = "sendgrid"
+		case os.Getenv("RENDER") != "":
+			log.Fatal("mail: no MAIL_BACKEND or SENDGRID_API_KEY in production; set MAIL_BACKEND=file to write messages to files anyway")
+		default:
+			backend = "file"
+		}
+	}
+
+	switch backend {
+	case "sendgrid":
+		return &SendGridMailer{APIKey: os.Getenv("SENDGRID_API_KEY"), From: from, FromName: defaultFromName}
+	case "smtp":
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &SMTPMailer{
+			Addr:     os.Getenv("SMTP_HOST") + ":" + port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+			FromName: defaultFromName,
+		}
+	default:
+		if backend != "file" {
+			if os.Getenv("RENDER") != "" {
+				log.Fatalf("mail: unknown MAIL_BACKEND %q", backend)
+			}
+			log.Printf("mail: unknown MAIL_BACKEND %q, writing messages to files", backend)
+		}
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = defaultMailDir
+		}
+		return &FileMailer{Dir: dir}
+	}
+}
+
+// withDefaults fills the sender fields and checks the message can be sent.
+func (m Message) withDefaults(from, fromName string) (Message, error) {
+	if len(m.To) == 0 {
+		return m, ErrNoRecipients
+	}
+	if m.From == "" {
+		m.From = from
+	}
+	if m.FromName == "" {
+		m.FromName = fromName
+	}
+	return m, nil
+}
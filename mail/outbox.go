@@ -0,0 +1,228 @@
+package mail
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	outboxPollInterval  = 5 * time.Second
+	outboxBatchSize     = 20
+	outboxClaimTTL      = 5 * time.Minute
+	outboxSentRetention = 30 * 24 * time.Hour
+	outboxDeadRetention = 7 * 24 * time.Hour // bodies of dead messages
+
+	// DefaultMaxAttempts is how many deliveries are tried before a message
+	// is marked dead; with Backoff that spans roughly a day.
+	DefaultMaxAttempts = 10
+
+	backoffBase = 30 * time.Second
+	backoffMax  = 6 * time.Hour
+)
+
+// scrubbedMessage drops the parts of a stored message that can carry live links
+// (verification, email change, unsubscribe) once it will not be sent again:
+// when it is sent, or when it has sat dead past outboxDeadRetention without
+// being re-queued. To and subject stay for the admin listing.
+const scrubbedMessage = `message - 'text' - 'html' - 'headers'`
+
+var (
+	ErrNotDead       = errors.New("mail: only dead messages can be re-queued")
+	ErrBodyDiscarded = errors.New("mail: message body was discarded")
+)
+
+// CheckRequeue reports whether a stored message with the given status can be
+// put back in the queue.
+func CheckRequeue(status string, stored []byte) error {
+	if status != "dead" {
+		return ErrNotDead
+	}
+	var msg Message
+	if err := json.Unmarshal(stored, &msg); err != nil || (msg.Text == "" && msg.HTML == "") {
+		return ErrBodyDiscarded
+	}
+	return nil
+}
+
+// Execer is satisfied by both *sql.DB and *sql.Tx.
+type Execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Enqueue stores msg for delivery by the outbox worker. Pass the transaction
+// that makes the related change so the email exists if and only if the
+// change commits.
+func Enqueue(db Execer, kind string, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO email_outbox (kind, message) VALUES ($1, $2)`, kind, string(b))
+	return err
+}
+
+// Backoff returns the wait before retrying after the given number of failed
+// attempts: 30s, 1m, 2m, ... capped at 6h.
+func Backoff(attempts int) time.Duration {
+	d := backoffBase
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= backoffMax {
+			return backoffMax
+		}
+	}
+	return d
+}
+
+// OutboxWorker delivers queued email. Several instances can run at once:
+// rows are claimed with FOR UPDATE SKIP LOCKED, and a claim left behind by a
+// crashed worker expires after a few minutes.
+type OutboxWorker struct {
+	DB          *sql.DB
+	Mailer      Mailer
+	MaxAttempts int // defaults to DefaultMaxAttempts
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (w *OutboxWorker) Run(ctx context.Context) {
+	poll := time.NewTicker(outboxPollInterval)
+	cleanup := time.NewTicker(time.Hour)
+	defer poll.Stop()
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			// Drain full batches before waiting for the next tick.
+			for {
+				n, err := w.ProcessBatch(ctx)
+				if err != nil {
+					log.Printf("email outbox: %v", err)
+					break
+				}
+				if n < outboxBatchSize {
+					break
+				}
+			}
+		case <-cleanup.C:
+			if _, err := w.DB.ExecContext(ctx,
+				`DELETE FROM email_outbox WHERE status = 'sent' AND sent_at < $1`,
+				time.Now().Add(-outboxSentRetention),
+			); err != nil {
+				log.Printf("email outbox cleanup: %v", err)
+			}
+			// A dead message's next_attempt_at is when it died; re-queuing
+			// resets it.
+			if _, err := w.DB.ExecContext(ctx, `
+				UPDATE email_outbox SET message = `+scrubbedMessage+`
+				WHERE status = 'dead' AND next_attempt_at < $1
+				  AND (message ? 'text' OR message ? 'html' OR message ? 'headers')`,
+				time.Now().Add(-outboxDeadRetention),
+			); err != nil {
+				log.Printf("email outbox cleanup: %v", err)
+			}
+		}
+	}
+}
+
+type claimedEmail struct {
+	id       int64
+	attempts int
+	msg      Message
+}
+
+// ProcessBatch claims and sends up to one batch of due messages, returning
+// how many were claimed.
+func (w *OutboxWorker) ProcessBatch(ctx context.Context) (int, error) {
+	rows, err := w.DB.QueryContext(ctx, `
+		UPDATE email_outbox
+		SET status = 'sending',
+		    attempts = attempts + 1,
+		    locked_until = now() + $2 * interval '1 second'
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE (status = 'pending' AND next_attempt_at <= now())
+			   OR (status = 'sending' AND locked_until < now())
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, attempts, message`,
+		outboxBatchSize, int(outboxClaimTTL.Seconds()),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var batch []claimedEmail
+	for rows.Next() {
+		var e claimedEmail
+		var raw []byte
+		if err := rows.Scan(&e.id, &e.attempts, &raw); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if err := json.Unmarshal(raw, &e.msg); err != nil {
+			// Undeliverable as stored; park it straight away.
+			w.fail(ctx, e.id, w.maxAttempts(), err)
+			continue
+		}
+		batch = append(batch, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, e := range batch {
+		sendCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		err := w.Mailer.Send(sendCtx, e.msg)
+		cancel()
+		if err != nil {
+			w.fail(ctx, e.id, e.attempts, err)
+			continue
+		}
+		if _, err := w.DB.ExecContext(ctx, `
+			UPDATE email_outbox
+			SET status = 'sent', sent_at = now(), locked_until = NULL, last_error = NULL,
+			    message = `+scrubbedMessage+`
+			WHERE id = $1`, e.id,
+		); err != nil {
+			log.Printf("email outbox: mark %d sent: %v", e.id, err)
+		}
+	}
+	return len(batch), nil
+}
+
+func (w *OutboxWorker) maxAttempts() int {
+	if w.MaxAttempts > 0 {
+		return w.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+func (w *OutboxWorker) fail(ctx context.Context, id int64, attempts int, sendErr error) {
+	status, next := "pending", time.Now().Add(Backoff(attempts))
+	if attempts >= w.maxAttempts() {
+		status, next = "dead", time.Now()
+		log.Printf("email outbox: message %d is dead after %d attempts: %v", id, attempts, sendErr)
+	}
+
+	if _, err := w.DB.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET status = $2, next_attempt_at = $3, locked_until = NULL, last_error = $4
+		WHERE id = $1`,
+		id, status, next, sendErr.Error(),
+	); err != nil {
+		log.Printf("email outbox: record failure for %d: %v", id, err)
+	}
+}
@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sendgrid/sendgrid-go"
+	sgmail "github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// SendGridMailer sends through the SendGrid v3 API.
+type SendGridMailer struct {
+	APIKey   string
+	From     string
+	FromName string
+}
+
+func (s *SendGridMailer) Send(ctx context.Context, msg Message) error {
+	msg, err := msg.withDefaults(s.From, s.FromName)
+	if err != nil {
+		return err
+	}
+
+	v3 := sgmail.NewV3Mail()
+	v3.SetFrom(sgmail.NewEmail(msg.FromName, msg.From))
+	v3.Subject = msg.Subject
+
+	p := sgmail.NewPersonalization()
+	for _, to := range msg.To {
+		p.AddTos(sgmail.NewEmail("", to))
+	}
+	v3.AddPersonalizations(p)
+	if msg.ReplyTo != "" {
+		v3.SetReplyTo(sgmail.NewEmail("", msg.ReplyTo))
+	}
+	for k, v := range msg.Headers {
+		v3.SetHeader(k, v)
+	}
+	v3.AddContent(sgmail.NewContent("text/plain", msg.Text))
+	if msg.HTML != "" {
+		v3.AddContent(sgmail.NewContent("text/html", msg.HTML))
+	}
+
+	client := sendgrid.NewSendClient(s.APIKey)
+	response, err := client.SendWithContext(ctx, v3)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("sendgrid error: %d - %s", response.StatusCode, response.Body)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends through a plain SMTP relay. STARTTLS is used when the
+// server offers it; credentials are only sent over an encrypted connection
+// (net/smtp refuses PLAIN auth otherwise, except to localhost).
+type SMTPMailer struct {
+	Addr     string // host:port
+	Username string // empty disables AUTH
+	Password string
+	From     string
+	FromName string
+}
+
+func (s *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	msg, err := msg.withDefaults(s.From, s.FromName)
+	if err != nil {
+		return err
+	}
+	body, err := buildMIME(msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	// smtp.SendMail has no context support; run it so cancellation at least
+	// unblocks the caller.
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(s.Addr, auth, msg.From, msg.To, body) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("smtp send: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// buildMIME renders msg as a multipart/alternative RFC 5322 message.
+func buildMIME(msg Message, now time.Time) ([]byte, error) {
+	var raw [12]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return nil, err
+	}
+	boundary := "gable-" + hex.EncodeToString(raw[:])
+
+	var b bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+
+	header("From", (&mail.Address{Name: msg.FromName, Address: msg.From}).String())
+	header("To", strings.Join(msg.To, ", "))
+	if msg.ReplyTo != "" {
+		header("Reply-To", msg.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	keys := make([]string, 0, len(msg.Headers))
+	for k := range msg.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		header(k, msg.Headers[k])
+	}
+	header("MIME-Version", "1.0")
+	header("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+	b.WriteString("\r\n")
+
+	part := func(contentType, content string) error {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s; charset=utf-8\r\n", contentType)
+		b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		w := quotedprintable.NewWriter(&b)
+		if _, err := w.Write([]byte(content)); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		b.WriteString("\r\n")
+		return nil
+	}
+	if err := part("text/plain", msg.Text); err != nil {
+		return nil, err
+	}
+	if msg.HTML != "" {
+		if err := part("text/html", msg.HTML); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes(), nil
+}
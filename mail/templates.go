@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Each email is a pair of templates: <name>.txt, which also defines a
+// "subject" template, and <name>.html. HTML bodies go through html/template
+// so user-provided values are escaped.
+//
+//go:embed templates/*.txt templates/*.html
+var templateFS embed.FS
+
+var (
+	// One set per .txt file, since every file defines its own "subject".
+	textTemplates = mustParseText()
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+)
+
+func mustParseText() map[string]*texttemplate.Template {
+	files, err := fs.Glob(templateFS, "templates/*.txt")
+	if err != nil {
+		panic(err)
+	}
+	sets := make(map[string]*texttemplate.Template, len(files))
+	for _, f := range files {
+		name := strings.TrimSuffix(path.Base(f), ".txt")
+		sets[name] = texttemplate.Must(texttemplate.ParseFS(templateFS, f))
+	}
+	return sets
+}
+
+// Render builds the subject and bodies of the named email. Recipients are
+// left for the caller.
+func Render(name string, data any) (Message, error) {
+	textTmpl, ok := textTemplates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("mail: no template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("mail: render %s subject: %w", name, err)
+	}
+	if err := textTmpl.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return Message{}, fmt.Errorf("mail: render %s text: %w", name, err)
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return Message{}, fmt.Errorf("mail: render %s html: %w", name, err)
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
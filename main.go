@@ -1,11 +1,26 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/url"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 	_ "time/tzdata"
 
 	"gable-backend/database"
+	"gable-backend/internal/cache"
+	"gable-backend/internal/clientip"
+	"gable-backend/internal/digest"
+	"gable-backend/internal/httpcache"
+	"gable-backend/internal/ratelimit"
+	"gable-backend/internal/reminder"
+	"gable-backend/internal/stats"
+	"gable-backend/mail"
+	"gable-backend/middleware"
 	"gable-backend/routes"
 
 	"github.com/gofiber/fiber/v2"
@@ -14,6 +29,10 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take to finish on
+// SIGTERM; Render allows 30 seconds before killing the process.
+const shutdownTimeout = 20 * time.Second
+
 func main() {
 	// Load env vars from .env file
 	if os.Getenv("RENDER") == "" {
@@ -25,6 +44,10 @@ func main() {
 	// Load environment variables
 	database.ConnectDB()
 	database.RunMigrations()
+	database.BootstrapAdmins()
+
+	// MAIL_BACKEND selects sendgrid, smtp or file (the default without SENDGRID_API_KEY).
+	mail.SetDefault(mail.FromEnv())
 
 	if os.Getenv("JWT_SECRET") == "" {
 		log.Fatal("JWT_SECRET environment variable not set")
@@ -35,20 +58,114 @@ func main() {
 		log.Fatal("PORT environment variable not set")
 	}
 
-	app := fiber.New()
+	// Behind a load balancer the client address arrives in a header (e.g.
+	// X-Forwarded-For); per-IP sign-in throttling depends on it. The header
+	// is only believed from TRUSTED_PROXIES (comma-separated IPs or CIDRs),
+	// since anyone else can set it to dodge the throttle, and only the
+	// entries those proxies added are read.
+	proxyHeader := os.Getenv("PROXY_HEADER")
+	trustedProxies, err := clientip.ParseTrusted(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatalf("TRUSTED_PROXIES: %v", err)
+	}
+	if proxyHeader != "" && len(trustedProxies) == 0 {
+		log.Println("PROXY_HEADER is set without TRUSTED_PROXIES; using the connection address")
+	}
+	app := fiber.New(fiber.Config{
+		ProxyHeader:             proxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          trustedProxies.Strings(),
+		EnableIPValidation:      true,
+	})
 
+	app.Use(middleware.ClientIP(proxyHeader, trustedProxies))
 	app.Use(fiberrecover.New())
+	// Starting an identity link sets a cookie binding the flow to the
+	// browser, so the SPA calls it with credentials, which CORS only allows
+	// for a named origin.
+	const identityLinkPath = "/api/gable/user/identities/link"
 	app.Use(cors.New(cors.Config{
+		Next:         func(c *fiber.Ctx) bool { return c.Path() == identityLinkPath },
 		AllowOrigins: "*",
 		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-API-Key",
 	}))
+	if u, err := url.Parse(os.Getenv("FRONTEND_URL")); err == nil && u.Scheme != "" && u.Host != "" {
+		app.Use(identityLinkPath, cors.New(cors.Config{
+			AllowOrigins:     u.Scheme + "://" + u.Host,
+			AllowCredentials: true,
+			AllowMethods:     "POST,OPTIONS",
+			AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		}))
+	}
+
+	// Background workers run until shutdown; each flushes or finishes its
+	// current step when the context is cancelled.
+	workers, stopWorkers := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	run := func(fn func(context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(workers)
+		}()
+	}
+
+	// Platform API quotas; the worker flushes usage counts and prunes old windows.
+	apiLimiter := ratelimit.NewStore(database.DB)
+	run(apiLimiter.Run)
+
+	// Platform data version behind v1 ETags; ingests and publishes bump it.
+	dataVersions := httpcache.NewVersions(database.DB)
+
+	// Drops cached queries (and the data version) when other instances or
+	// ingests commit changes, announced with NOTIFY.
+	cache.Default.OnInvalidate(func(string) { dataVersions.Expire() })
+	run(func(ctx context.Context) { cache.Default.Listen(ctx, os.Getenv("DATABASE_URL")) })
+
+	// Delivers queued email with retries; handlers only write to email_outbox.
+	outbox := &mail.OutboxWorker{DB: database.DB, Mailer: mail.Default()}
+	run(outbox.Run)
+
+	// Queues the weekly rankings digest on Monday mornings (America/New_York).
+	digests := digest.NewStore(database.DB, digest.ConfigFromEnv())
+	run(digests.Run)
+
+	// Opt-in daily reminders for players who have not played yet.
+	reminders := reminder.NewStore(database.DB, os.Getenv("FRONTEND_URL"))
+	run(reminders.Run)
+
+	// Computes stat lines for seasons with bouts but none stored yet, or
+	// older than their newest batch; imports refresh their own seasons.
+	run(func(ctx context.Context) {
+		if err := stats.NewStore(database.DB).Backfill(ctx); err != nil {
+			log.Printf("stats backfill error: %v", err)
+		}
+	})
 
 	// Setup routes
 	routes.WrestlerRoutes(app)
-	routes.PlatformRoutes(app)
+	routes.PlatformRoutes(app, apiLimiter, dataVersions)
 
 	// Start server
-	log.Println("Server running on port " + port)
-	log.Fatal(app.Listen(":" + port))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		log.Println("Server running on port " + port)
+		if err := app.Listen(":" + port); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	<-ctx.Done()
+
+	// Finish in-flight requests, then let the workers flush (API usage
+	// counts above all) before exiting.
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	stopWorkers()
+	wg.Wait()
 }
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gable-backend/database"
+	"gable-backend/internal/apikey"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+// AnonymousTier is the tier of /api/v1 requests made without a key.
+const AnonymousTier = "anonymous"
+
+// APIKeyHeader carries the platform API key.
+const APIKeyHeader = "X-API-Key"
+
+// APIClient describes the caller of an /api/v1 request.
+type APIClient struct {
+	KeyID  string // empty for anonymous callers
+	UserID int
+	Tier   string
+	Scopes []string
+}
+
+// HasScope reports whether the client may call routes guarded by scope.
+// Anonymous callers get every read scope, limited only by their tier.
+func (a APIClient) HasScope(scope string) bool {
+	if a.KeyID == "" {
+		return true
+	}
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey identifies /api/v1 callers by the X-API-Key header. Requests without
+// a key continue as the anonymous tier; a key that is present but invalid,
+// expired or revoked is rejected rather than silently downgraded.
+func APIKey(c *fiber.Ctx) error {
+	raw := strings.TrimSpace(c.Get(APIKeyHeader))
+	if raw == "" {
+		c.Locals("api_client", APIClient{Tier: AnonymousTier})
+		return c.Next()
+	}
+
+	prefix, secret, ok := apikey.Parse(raw)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid API key"})
+	}
+
+	var (
+		client    APIClient
+		hash      string
+		expiresAt sql.NullTime
+		revokedAt sql.NullTime
+		lastUsed  sql.NullTime
+	)
+	err := database.DB.QueryRow(`
+		SELECT id, user_id, tier, scopes, key_hash, expires_at, revoked_at, last_used_at
+		FROM api_keys
+		WHERE prefix = $1
+	`, prefix).Scan(&client.KeyID, &client.UserID, &client.Tier, pq.Array(&client.Scopes),
+		&hash, &expiresAt, &revokedAt, &lastUsed)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !apikey.Matches(secret, hash)) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid API key"})
+	}
+	if err != nil {
+		log.Printf("api key lookup error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+	if revokedAt.Valid {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "API key has been revoked"})
+	}
+	if expiresAt.Valid && time.Now().After(expiresAt.Time) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "API key has expired"})
+	}
+
+	// last_used_at is informational; write it at most once a minute per key.
+	if !lastUsed.Valid || time.Since(lastUsed.Time) > time.Minute {
+		if _, err := database.DB.Exec(`UPDATE api_keys SET last_used_at = now() WHERE id = $1`, client.KeyID); err != nil {
+			log.Printf("api key last_used_at: %v", err)
+		}
+	}
+
+	c.Locals("api_client", client)
+	return c.Next()
+}
+
+// RequireAPIScope rejects keys that were not granted scope. It must run after APIKey.
+func RequireAPIScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		client, _ := c.Locals("api_client").(APIClient)
+		if !client.HasScope(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API key is missing scope: " + scope})
+		}
+		return c.Next()
+	}
+}
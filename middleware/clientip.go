@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"gable-backend/internal/clientip"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientIP narrows header, on requests from a trusted proxy, to the one
+// client address resolved from it, so c.IP() and the per-IP throttles and
+// quotas keyed on it cannot be steered by a prefix the client sent. Fiber
+// ignores the header on requests from anyone else. Register it first.
+func ClientIP(header string, trusted clientip.Trusted) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if header == "" || !c.IsProxyTrusted() {
+			return c.Next()
+		}
+		if v := c.Get(header); v != "" {
+			if ip := trusted.Resolve(v); ip != "" {
+				c.Request().Header.Set(header, ip)
+			} else {
+				c.Request().Header.Del(header)
+			}
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"gable-backend/internal/httpcache"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HTTPCache tags successful responses with a strong ETag derived from the
+// platform data version, the URL and the caller's scopes, and sets
+// Cache-Control from policy. A request whose If-None-Match already holds the
+// current tag is answered 304 without running the handler. It must run after
+// APIKey and the scope guard. If the version cannot be read the response is
+// served uncached.
+func HTTPCache(versions *httpcache.Versions, policy httpcache.Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Vary("X-API-Key")
+		version, err := versions.Current(c.Context())
+		if err != nil {
+			log.Printf("httpcache version: %v", err)
+			c.Set(fiber.HeaderCacheControl, "no-cache")
+			return c.Next()
+		}
+
+		client, _ := c.Locals("api_client").(APIClient)
+		scopes := "*"
+		if client.KeyID != "" {
+			s := append([]string(nil), client.Scopes...)
+			sort.Strings(s)
+			scopes = strings.Join(s, ",")
+		}
+		tag := httpcache.Tag(version, c.OriginalURL(), scopes)
+
+		if inm := c.Get(fiber.HeaderIfNoneMatch); inm != "" && httpcache.Matches(inm, tag) {
+			c.Set(fiber.HeaderETag, tag)
+			c.Set(fiber.HeaderCacheControl, policy.Header())
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() == fiber.StatusOK {
+			c.Set(fiber.HeaderETag, tag)
+			c.Set(fiber.HeaderCacheControl, policy.Header())
+		}
+		return nil
+	}
+}
+
+// CacheControl sets a fixed Cache-Control header on every response.
+func CacheControl(value string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, value)
+		return c.Next()
+	}
+}
@@ -1,14 +1,18 @@
 package middleware
 
 import (
-	"fmt"
-	"os"
 	"strings"
 
+	"gable-backend/database"
+	"gable-backend/internal/auth"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
+// RequireAuth validates the Bearer access token and confirms that the session
+// it was issued for has not been revoked or expired. The email local is read
+// from the users row rather than the token claim, so it reflects an email
+// change immediately instead of after the next refresh.
 func RequireAuth(c *fiber.Ctx) error {
 	tokenString := c.Get("Authorization")
 	if tokenString == "" {
@@ -18,71 +22,25 @@ func RequireAuth(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid header format"})
 	}
 
-	rawToken := tokenString[len("Bearer "):]
-	token, err := jwt.Parse(rawToken, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-
-	if err != nil || token == nil || !token.Valid {
+	claims, err := auth.ParseAccessToken(tokenString[len("Bearer "):])
+	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired token"})
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token claims"})
-	}
-
-	userIDFloat, ok := claims["user_id"].(float64)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing user ID in token"})
+	var email string
+	err = database.DB.QueryRow(`
+		SELECT u.email
+		FROM user_sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = $1 AND s.user_id = $2 AND s.revoked_at IS NULL AND s.expires_at > now()
+	`, claims.SessionID, claims.UserID).Scan(&email)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Session has ended"})
 	}
-	c.Locals("user_id", int(userIDFloat))
 
-	email, _ := claims["email"].(string)
-	email = strings.ToLower(strings.TrimSpace(email))
-	if email == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing email in token"})
-	}
-	c.Locals("email", email)
+	c.Locals("user_id", claims.UserID)
+	c.Locals("email", strings.ToLower(strings.TrimSpace(email)))
+	c.Locals("session_id", claims.SessionID)
 
 	return c.Next()
 }
-
-func RequireAdmin() fiber.Handler {
-	allowed := parseAllowedEmails(os.Getenv("ADMIN_EMAILS"))
-
-	return func(c *fiber.Ctx) error {
-		if len(allowed) == 0 {
-			return c.Status(fiber.StatusInternalServerError).
-				JSON(fiber.Map{"error": "ADMIN_EMAILS is not configured"})
-		}
-
-		emailAny := c.Locals("email")
-		email, _ := emailAny.(string)
-		email = strings.ToLower(strings.TrimSpace(email))
-
-		if email == "" {
-			return c.SendStatus(fiber.StatusUnauthorized)
-		}
-
-		if !allowed[email] {
-			return c.SendStatus(fiber.StatusForbidden)
-		}
-
-		return c.Next()
-	}
-}
-
-func parseAllowedEmails(raw string) map[string]bool {
-	m := make(map[string]bool)
-	for _, e := range strings.Split(raw, ",") {
-		e = strings.TrimSpace(strings.ToLower(e))
-		if e != "" {
-			m[e] = true
-		}
-	}
-	return m
-}
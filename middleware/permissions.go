@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"log"
+
+	"gable-backend/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission allows the request through only when one of the caller's
+// roles grants perm. It must run after RequireAuth.
+func RequirePermission(perm string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("user_id").(int)
+		if !ok {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+
+		allowed, err := UserHasPermission(userID, perm)
+		if err != nil {
+			log.Printf("permission check error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check permissions"})
+		}
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Missing permission: " + perm})
+		}
+
+		return c.Next()
+	}
+}
+
+// UserHasPermission reports whether any role granted to userID includes perm.
+func UserHasPermission(userID int, perm string) (bool, error) {
+	var allowed bool
+	err := database.DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM user_roles ur
+			JOIN role_permissions rp ON rp.role_id = ur.role_id
+			WHERE ur.user_id = $1 AND rp.permission = $2
+		)
+	`, userID, perm).Scan(&allowed)
+	return allowed, err
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"gable-backend/internal/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimit enforces the caller's tier quota and records usage. Keys are
+// limited per key; anonymous callers per client IP. It must run after APIKey.
+// If the counters cannot be reached the request is allowed through.
+func RateLimit(store *ratelimit.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		client, _ := c.Locals("api_client").(APIClient)
+		now := time.Now()
+
+		subject, usageSubject := "ip:"+c.IP(), AnonymousTier
+		if client.KeyID != "" {
+			subject = "key:" + client.KeyID
+			usageSubject = subject
+		}
+
+		limits, err := store.Limits(c.Context(), client.Tier)
+		if err != nil {
+			log.Printf("ratelimit limits: %v", err)
+			return recordAfter(c, store, usageSubject, now)
+		}
+		minuteCount, dayCount, err := store.Hit(c.Context(), subject, now)
+		if err != nil {
+			log.Printf("ratelimit hit: %v", err)
+			return recordAfter(c, store, usageSubject, now)
+		}
+
+		d := ratelimit.Decide(limits, minuteCount, dayCount, now)
+		if d.Limit > 0 {
+			c.Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+			c.Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			c.Set("X-RateLimit-Reset", strconv.FormatInt(d.Reset.Unix(), 10))
+		}
+		if !d.Allowed {
+			secs := int(d.RetryAfter.Round(time.Second).Seconds())
+			if secs < 1 {
+				secs = 1
+			}
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(secs))
+			store.RecordUsage(usageSubject, c.Route().Path, fiber.StatusTooManyRequests, now)
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":       "Rate limit exceeded",
+				"tier":        client.Tier,
+				"retry_after": secs,
+			})
+		}
+
+		return recordAfter(c, store, usageSubject, now)
+	}
+}
+
+func recordAfter(c *fiber.Ctx, store *ratelimit.Store, subject string, now time.Time) error {
+	err := c.Next()
+	status := c.Response().StatusCode()
+	if err != nil {
+		if fe, ok := err.(*fiber.Error); ok {
+			status = fe.Code
+		} else {
+			status = fiber.StatusInternalServerError
+		}
+	}
+	store.RecordUsage(subject, c.Route().Path, status, now)
+	return err
+}
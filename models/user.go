@@ -1,11 +1,14 @@
 package models
 
 type User struct {
-	ID                int    `json:"id"`
-	Email             string `json:"email"`
-	PasswordHash      string `json:"-"`
-	Verified          bool   `json:"verified"`
-	VerificationToken string `json:"-"`
+	ID                int      `json:"id"`
+	Email             string   `json:"email"`
+	DisplayName       *string  `json:"display_name"`
+	PasswordHash      string   `json:"-"`
+	Verified          bool     `json:"verified"`
+	VerificationToken string   `json:"-"`
+	Roles             []string `json:"roles"`
+	Permissions       []string `json:"permissions"`
 }
 
 type GuessInput struct {
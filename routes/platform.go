@@ -1,31 +1,74 @@
 package routes
 
 import (
+	"time"
+
 	"gable-backend/controllers"
+	"gable-backend/internal/apikey"
+	"gable-backend/internal/httpcache"
+	"gable-backend/internal/ratelimit"
+	"gable-backend/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // PlatformRoutes mounts the versioned platform API at /api/v1.
-// All endpoints are read-only and require no authentication.
-func PlatformRoutes(app *fiber.App) {
-	v1 := app.Group("/api/v1")
+// All endpoints are read-only. Callers may identify with an X-API-Key header;
+// without one they are served as the anonymous tier. Keys only reach routes
+// whose scope they were granted. Wrestler, school and conference identifiers
+// may be a UUID or a slug; retired slugs redirect to the current one.
+//
+// The rate limiter is attached per route rather than on the group so usage
+// is recorded against the route pattern, including for rejected requests.
+// Data routes carry ETags from the platform data version and answer
+// If-None-Match with 304; rarely changing lists may be cached for longer.
+func PlatformRoutes(app *fiber.App, limiter *ratelimit.Store, versions *httpcache.Versions) {
+	// Documentation; not rate limited and not listed in the spec itself.
+	// Registered ahead of the group so a bad X-API-Key cannot hide the docs.
+	docs := middleware.CacheControl("public, max-age=3600")
+	app.Get("/api/v1/openapi.json", docs, controllers.V1OpenAPISpec)
+	app.Get("/api/v1/docs", docs, controllers.V1Docs)
+
+	v1 := app.Group("/api/v1", middleware.APIKey)
+	limit := middleware.RateLimit(limiter)
+	live := middleware.HTTPCache(versions, httpcache.Policy{MaxAge: time.Minute, StaleWhileRevalidate: 5 * time.Minute})
+	reference := middleware.HTTPCache(versions, httpcache.Policy{MaxAge: time.Hour, StaleWhileRevalidate: 24 * time.Hour})
+
+	// Key owner
+	v1.Get("/me/usage", limit, middleware.CacheControl("private, no-store"), controllers.V1GetMyUsage)
 
 	// Wrestlers
-	v1.Get("/wrestlers", controllers.V1GetWrestlers)
-	v1.Get("/wrestlers/:id", controllers.V1GetWrestler)
+	wrestlers := middleware.RequireAPIScope(apikey.ScopeWrestlers)
+	v1.Get("/wrestlers", limit, wrestlers, live, controllers.V1GetWrestlers)
+	v1.Get("/wrestlers/:id", limit, wrestlers, live, controllers.V1GetWrestler)
 
 	// Schools
-	v1.Get("/schools", controllers.V1GetSchools)
-	v1.Get("/schools/:slug", controllers.V1GetSchool)
+	schools := middleware.RequireAPIScope(apikey.ScopeSchools)
+	v1.Get("/schools", limit, schools, reference, controllers.V1GetSchools)
+	v1.Get("/schools/:slug", limit, schools, live, controllers.V1GetSchool)
 
 	// Conferences
-	v1.Get("/conferences", controllers.V1GetConferences)
+	v1.Get("/conferences", limit, schools, reference, controllers.V1GetConferences)
 
 	// Seasons
-	v1.Get("/seasons", controllers.V1GetSeasons)
+	v1.Get("/seasons", limit, schools, reference, controllers.V1GetSeasons)
 
 	// Rankings
-	v1.Get("/rankings", controllers.V1GetRankings)
-	v1.Get("/rankings/history/:wrestler_id", controllers.V1GetWrestlerRankingHistory)
+	rankings := middleware.RequireAPIScope(apikey.ScopeRankings)
+	v1.Get("/rankings", limit, rankings, live, controllers.V1GetRankings)
+	v1.Get("/rankings/history/:wrestler", limit, rankings, live, controllers.V1GetWrestlerRankingHistory)
+
+	// Results
+	results := middleware.RequireAPIScope(apikey.ScopeResults)
+	v1.Get("/results/bouts", limit, results, live, controllers.V1GetBouts)
+	v1.Get("/results/duals", limit, results, live, controllers.V1GetDuals)
+	v1.Get("/wrestlers/:id/results", limit, results, live, controllers.V1GetWrestlerResults)
+	v1.Get("/head-to-head", limit, results, live, controllers.V1GetHeadToHead)
+	v1.Get("/head-to-head/common-opponents", limit, results, live, controllers.V1GetCommonOpponents)
+
+	// Stats
+	v1.Get("/stats/wrestler/:slug", limit, results, live, controllers.V1GetWrestlerStats)
+
+	// Search checks scopes per result type itself.
+	v1.Get("/search", limit, live, controllers.V1Search)
 }
@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"gable-backend/internal/apidocs"
+	"gable-backend/internal/httpcache"
+	"gable-backend/internal/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type openAPIDoc struct {
+	Paths map[string]map[string]struct {
+		Parameters []struct {
+			Name string `json:"name"`
+			In   string `json:"in"`
+		} `json:"parameters"`
+	} `json:"paths"`
+}
+
+// undocumented are v1 routes the spec leaves out on purpose.
+var undocumented = map[string]bool{
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+}
+
+var (
+	fiberParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+	specParam  = regexp.MustCompile(`\{([^}]+)\}`)
+)
+
+func TestOpenAPIMatchesPlatformRoutes(t *testing.T) {
+	app := fiber.New()
+	PlatformRoutes(app, ratelimit.NewStore(nil), httpcache.NewVersions(nil))
+
+	registered := map[string]bool{}
+	for _, r := range app.GetRoutes(true) {
+		if r.Method == fiber.MethodHead || !strings.HasPrefix(r.Path, "/api/v1/") {
+			continue
+		}
+		path := fiberParam.ReplaceAllString(strings.TrimPrefix(r.Path, "/api/v1"), "{$1}")
+		key := r.Method + " " + path
+		if !undocumented[key] {
+			registered[key] = true
+		}
+	}
+
+	var doc openAPIDoc
+	if err := json.Unmarshal(apidocs.Spec, &doc); err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+	documented := map[string]bool{}
+	for path, ops := range doc.Paths {
+		for method, op := range ops {
+			documented[strings.ToUpper(method)+" "+path] = true
+
+			inPath := map[string]bool{}
+			for _, p := range op.Parameters {
+				if p.In == "path" {
+					inPath[p.Name] = true
+				}
+			}
+			for _, m := range specParam.FindAllStringSubmatch(path, -1) {
+				if !inPath[m[1]] {
+					t.Errorf("%s %s: path parameter %q is not declared", method, path, m[1])
+				}
+				delete(inPath, m[1])
+			}
+			for name := range inPath {
+				t.Errorf("%s %s: declares path parameter %q not in the path", method, path, name)
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(registered) {
+		if !documented[key] {
+			t.Errorf("route %s is not in openapi.json", key)
+		}
+	}
+	for _, key := range sortedKeys(documented) {
+		if !registered[key] {
+			t.Errorf("openapi.json documents %s, which is not registered", key)
+		}
+	}
+}
+
+func TestDocsIgnoreAPIKey(t *testing.T) {
+	app := fiber.New()
+	PlatformRoutes(app, ratelimit.NewStore(nil), httpcache.NewVersions(nil))
+
+	for _, path := range []string{"/api/v1/openapi.json", "/api/v1/docs"} {
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		req.Header.Set("X-API-Key", "gbl_revoked_or_mistyped")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("GET %s with a bad key = %d, want 200", path, resp.StatusCode)
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
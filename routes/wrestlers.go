@@ -18,10 +18,7 @@ func WrestlerRoutes(app *fiber.App) {
 
 	api := app.Group("/api/gable")
 
-	admin := app.Group("/api/admin",
-		middleware.RequireAuth,
-		middleware.RequireAdmin(),
-	)
+	admin := app.Group("/api/admin", middleware.RequireAuth)
 
 	//GET Requests
 	api.Get("/wrestlers", controllers.GetWrestlersByQuery)
@@ -29,12 +26,29 @@ func WrestlerRoutes(app *fiber.App) {
 	api.Get("/me", middleware.RequireAuth, controllers.GetMe)
 	api.Get("/user/guesses", middleware.RequireAuth, controllers.GetUserGuesses)
 	api.Get("/user/stats", middleware.RequireAuth, controllers.GetUserStats)
+	api.Get("/user/sessions", middleware.RequireAuth, controllers.ListSessions)
+	api.Get("/user/identities", middleware.RequireAuth, controllers.ListIdentities)
+	api.Get("/user/profile", middleware.RequireAuth, controllers.GetProfile)
+	api.Get("/user/api-keys", middleware.RequireAuth, controllers.ListAPIKeys)
+	api.Get("/user/digest", middleware.RequireAuth, controllers.GetDigestSubscription)
+	api.Get("/user/notifications", middleware.RequireAuth, controllers.GetNotificationPreferences)
+	api.Get("/users/:name", controllers.GetPublicProfile)
+	api.Get("/oidc/login", controllers.OIDCLogin)
+	api.Get("/oidc/callback", controllers.OIDCCallback)
 
 	//POST Requests
 	api.Post("/register", controllers.Register)
 	api.Post("/login", controllers.Login)
+	api.Post("/token/refresh", controllers.RefreshSession)
+	api.Post("/logout", middleware.RequireAuth, controllers.Logout)
+	api.Post("/user/identities/link", middleware.RequireAuth, controllers.LinkIdentity)
+	api.Post("/user/email", middleware.RequireAuth, controllers.RequestEmailChange)
+	api.Post("/user/email/confirm", controllers.ConfirmEmailChange)
+	api.Post("/user/api-keys", middleware.RequireAuth, controllers.CreateAPIKey)
+	api.Post("/user/api-keys/:id/rotate", middleware.RequireAuth, controllers.RotateAPIKey)
 	api.Post("/verify-email", controllers.VerifyEmail)
 	api.Post("/resend-verification", controllers.ResendVerification)
+	api.Post("/digest/unsubscribe", controllers.UnsubscribeDigest)
 	api.Post("/user/guess", middleware.RequireAuth, controllers.SubmitUserGuess)
 	api.Post("/user/stats", middleware.RequireAuth, controllers.UpdateUserStats)
 	api.Post("/contact", middleware.RequireAuth, limiter.New(limiter.Config{
@@ -42,19 +56,61 @@ func WrestlerRoutes(app *fiber.App) {
 		Expiration: time.Minute,
 	}), controllers.ContactHandler)
 
-	admin.Get("/rankings/releases", controllers.ListRankingsReleases)
-	admin.Get("/rankings/releases/:id", controllers.GetRankingsReleaseDetail)
-	admin.Get("/wrestlestat/candidates", controllers.GetWrestleStatCandidates)
+	//PUT Requests
+	api.Put("/user/profile", middleware.RequireAuth, controllers.UpdateProfile)
+	api.Put("/user/digest", middleware.RequireAuth, controllers.UpdateDigestSubscription)
+	api.Put("/user/notifications", middleware.RequireAuth, controllers.UpdateNotificationPreferences)
 
-	admin.Post("/rankings/releases", controllers.CreateRankingsRelease)
-	admin.Post("/rankings/releases/:id/import", controllers.ImportRankingsStaging)
-	admin.Post("/rankings/releases/:id/publish", controllers.PublishRankingsRelease)
-	admin.Post("/rankings/staging/attach", controllers.AttachWrestleStatIDs)
-	admin.Post("/rankings/releases/:id/resolve/lookup", controllers.BulkLookupWrestleStatCandidates)
-	admin.Post("/rankings/releases/:id/enrich", controllers.EnrichRankingsRelease)
+	//DELETE Requests
+	api.Delete("/user/sessions", middleware.RequireAuth, controllers.RevokeAllSessions)
+	api.Delete("/user/sessions/:id", middleware.RequireAuth, controllers.RevokeSession)
+	api.Delete("/user/identities/:id", middleware.RequireAuth, controllers.UnlinkIdentity)
+	api.Delete("/user/api-keys/:id", middleware.RequireAuth, controllers.RevokeAPIKey)
 
-	admin.Delete("/rankings/releases/:id/staging", controllers.ClearRankingsStagingForWeight)
+	admin.Get("/rankings/releases", middleware.RequirePermission("rankings.read"), controllers.ListRankingsReleases)
+	admin.Get("/rankings/releases/:id", middleware.RequirePermission("rankings.read"), controllers.GetRankingsReleaseDetail)
+	admin.Get("/wrestlestat/candidates", middleware.RequirePermission("rankings.write"), controllers.GetWrestleStatCandidates)
+
+	admin.Post("/rankings/releases", middleware.RequirePermission("rankings.write"), controllers.CreateRankingsRelease)
+	admin.Post("/rankings/releases/:id/import", middleware.RequirePermission("rankings.write"), controllers.ImportRankingsStaging)
+	admin.Post("/rankings/releases/:id/publish", middleware.RequirePermission("rankings.publish"), controllers.PublishRankingsRelease)
+	admin.Post("/rankings/staging/attach", middleware.RequirePermission("rankings.write"), controllers.AttachWrestleStatIDs)
+	admin.Post("/rankings/releases/:id/resolve/lookup", middleware.RequirePermission("rankings.write"), controllers.BulkLookupWrestleStatCandidates)
+	admin.Post("/rankings/releases/:id/enrich", middleware.RequirePermission("rankings.write"), controllers.EnrichRankingsRelease)
+
+	admin.Delete("/rankings/releases/:id/staging", middleware.RequirePermission("rankings.write"), controllers.ClearRankingsStagingForWeight)
 
 	// Results ingestion
-	admin.Post("/results/import/trackdual", controllers.ImportTrackDualCSV)
+	admin.Post("/results/import/trackdual", middleware.RequirePermission("results.import"), controllers.ImportTrackDualCSV)
+
+	// Users and roles
+	admin.Get("/users", middleware.RequirePermission("users.read"), controllers.SearchUsers)
+	admin.Get("/roles", middleware.RequirePermission("roles.manage"), controllers.ListRoles)
+	admin.Get("/users/:id/roles", middleware.RequirePermission("roles.manage"), controllers.GetUserRoles)
+	admin.Post("/users/:id/roles", middleware.RequirePermission("roles.manage"), controllers.GrantUserRole)
+	admin.Delete("/users/:id/roles/:role", middleware.RequirePermission("roles.manage"), controllers.RevokeUserRole)
+	admin.Delete("/users/:id/display-name", middleware.RequirePermission("users.manage"), controllers.ResetDisplayName)
+
+	// Sign-in lockouts
+	admin.Get("/security/lockouts", middleware.RequirePermission("users.manage"), controllers.ListLockouts)
+	admin.Delete("/security/lockouts", middleware.RequirePermission("users.manage"), controllers.ClearLockout)
+
+	// Platform API keys
+	admin.Get("/api/tiers", middleware.RequirePermission("api.manage"), controllers.ListAPITiers)
+	admin.Get("/api/usage", middleware.RequirePermission("api.manage"), controllers.GetAPIUsageSummary)
+	admin.Put("/api/keys/:id/tier", middleware.RequirePermission("api.manage"), controllers.SetAPIKeyTier)
+
+	// Audit log
+	admin.Get("/audit", middleware.RequirePermission("audit.read"), controllers.ListAuditLog)
+
+	// Outgoing email
+	admin.Get("/email/outbox", middleware.RequirePermission("email.manage"), controllers.ListEmailOutbox)
+	admin.Post("/email/outbox/:id/requeue", middleware.RequirePermission("email.manage"), controllers.RequeueOutboxEmail)
+	admin.Get("/digest/preview", middleware.RequirePermission("email.manage"), controllers.PreviewDigest)
+
+	// Contact inbox
+	admin.Get("/contact", middleware.RequirePermission("contact.manage"), controllers.ListContactMessages)
+	admin.Get("/contact/:id", middleware.RequirePermission("contact.manage"), controllers.GetContactMessage)
+	admin.Post("/contact/:id/reply", middleware.RequirePermission("contact.manage"), controllers.ReplyContactMessage)
+	admin.Post("/contact/:id/close", middleware.RequirePermission("contact.manage"), controllers.CloseContactMessage)
 }